@@ -2,14 +2,16 @@ package config
 
 import (
 	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"reflect"
 	"strings"
 
-	"github.com/davecgh/go-spew/spew"
-
+	"github.com/hydronica/go-config/internal/encode"
 	"github.com/hydronica/go-config/internal/encode/env"
 	"github.com/hydronica/go-config/internal/encode/file"
 	flg "github.com/hydronica/go-config/internal/encode/flag"
@@ -26,15 +28,27 @@ type goConfig struct {
 	// special flags
 	showVersion *bool
 	appName     string // self proclaimed app name.
-	showConfig  *bool
+	showConfig  *showFlag
 	version     string
 	description string
 	genConfig   *string
-	configPath  *string
-
-	defaultConfigPath string
-
-	flags *flg.Flags
+	configPath  *configPaths
+	profile     *string
+
+	defaultConfigPaths []string
+	configMapDirs      []string
+	configEnv          string // env variable name prefix of a whole config document
+	envPrefix          string // prepended to every field's env name
+	defaultProfile     string
+
+	flagSep string // joins nested struct flag prefixes (ie -db.host)
+	flags   *flg.Flags
+
+	provenance map[string]*Provenance // source of each field by path
+	defaults   interface{}            // copy of the config before the sources are loaded (see Reload)
+	resolvers  map[string]Resolver    // secret reference resolvers by scheme
+	sources    []source               // added sources (see AddSource)
+	precedence []SourceKind           // source load order (see Precedence)
 }
 
 // Validator can be used as a way to validate the state of a config
@@ -47,7 +61,9 @@ type Validator interface {
 // it goes through the struct and sets up corresponding flags to be used during parsing
 func New(c interface{}) *goConfig {
 	return &goConfig{
-		options: defaultOpts,
+		options:   defaultOpts,
+		flagSep:   flg.DotSeparator,
+		resolvers: make(map[string]Resolver),
 
 		config: c,
 	}
@@ -56,14 +72,18 @@ func New(c interface{}) *goConfig {
 type Options uint64
 
 const (
-	OptEnv Options = 1 << iota
-	OptEnvFile  // load .env from working directory
+	OptEnv     Options = 1 << iota
+	OptEnvFile         // load .env from working directory
 	OptToml
 	OptYaml
 	OptJson
 	OptFlag
-	OptGenConf  // -g to generate config files
-	OptShow     // -show to show the set config values
+	OptGenConf       // -g to generate config files
+	OptShow          // -show to show the set config values
+	OptEnvFileSuffix // read <NAME>_FILE as a file path when <NAME> is not set (opt-in)
+	OptEnvUnprefixed // fall back to the env name without the EnvPrefix (opt-in)
+	OptHcl           // load .hcl config files (opt-in)
+	OptJsonc         // allow comments and trailing commas in .json files (opt-in)
 )
 const OptFiles = OptToml | OptYaml | OptJson
 const defaultOpts = OptEnv | OptFiles | OptFlag | OptShow | OptGenConf | OptEnvFile
@@ -78,11 +98,21 @@ const defaultOpts = OptEnv | OptFiles | OptFlag | OptShow | OptGenConf | OptEnvF
 // OptFlag: ignore flag config files
 // OptGenConf: remove flag option to generate config files
 // OptShow: remove flag option to print of config values
+// OptEnvFileSuffix: ignore <NAME>_FILE env variables (disabled by default)
+// OptEnvUnprefixed: ignore env names without the EnvPrefix (disabled by default)
+// OptHcl: ignore hcl config files (disabled by default)
+// OptJsonc: read .json files as strict json (disabled by default)
 func (g *goConfig) Disable(opts Options) *goConfig {
 	g.options &^= opts
 	return g
 }
 
+// Enable Options that are disabled by default (ie OptEnvFileSuffix, OptHcl)
+func (g *goConfig) Enable(opts Options) *goConfig {
+	g.options |= opts
+	return g
+}
+
 // SetOptions overrides the default Options to just set the desired options
 // Example SetOptions(OptFiles | OptGenConf | OptShow)
 func (g *goConfig) SetOptions(opts Options) *goConfig {
@@ -106,14 +136,21 @@ func (g *goConfig) LoadOrDie() {
 
 // Load the configs in the following priority from most passive to most active:
 //
-// 1. Defaults
-// 2. Environment variables and the working directory ".env" file (read line by line;
-//    for each struct field, a non-empty value on that key in ".env" overrides os.Getenv)
-//    mapped into the struct
-// 3. File (toml, yaml, json)
-// 4. Flags (exception of config and version flag which are processed first)
+//  1. Defaults
+//  2. Environment variables and the working directory ".env" file (read line by line;
+//     for each struct field, a non-empty value on that key in ".env" overrides os.Getenv)
+//     mapped into the struct
+//  3. Kubernetes ConfigMap or Secret directories (see ConfigMap)
+//  4. File (toml, yaml, json, jsonc, json5, ini, properties, hcl)
+//  5. Flags (exception of config and version flag which are processed first)
 //
-// After the configs are loaded validate the result if config is a Validator.
+// Sources added with AddSource are loaded in between based on their priority
+// and the order may be changed with Precedence.
+//
+// After the configs are loaded secret references (ie file:///run/secrets/db_pw) are
+// resolved for the registered schemes, see RegisterResolver. Then all fields with the `req:"true"` tag are checked
+// and every unset required field is reported in a single error. Then the result
+// is validated if config is a Validator.
 //
 // Defaults are loaded first (on struct initialization by the user) then env variables supplant
 // defaults and then file config values are loaded which supplant env or default values and finally
@@ -122,15 +159,16 @@ func (g *goConfig) LoadOrDie() {
 // Before loading values, special flags (ie -help, -show, -config, -gen) are processed.
 func (g *goConfig) Load() error {
 	if g.options.isEnabled(OptShow) {
-		g.showConfig = flag.Bool("show", false, "print out the value of the config")
+		g.showConfig = new(showFlag)
+		flag.Var(g.showConfig, "show", "print out the value of the config (table,json,yaml)")
 	}
 
 	var f *flg.Flags
 	var err error
 	if g.options.isEnabled(OptFlag) {
-		f, err = flg.New(g.config)
+		f, err = flg.NewWithSeparator(g.config, g.flagSep)
 	} else { // don't add flags when disabled
-		f, err = flg.New(nil)
+		f, err = flg.NewWithSeparator(nil, g.flagSep)
 	}
 
 	if err != nil {
@@ -140,11 +178,20 @@ func (g *goConfig) Load() error {
 
 	if g.options.isEnabled(OptFiles) {
 		if g.options.isEnabled(OptGenConf) {
-			g.genConfig = flag.String("g", "", "generate config file (toml,json,yaml,env)")
+			g.genConfig = flag.String("g", "", "generate config file (toml,json,jsonc,yaml,ini,properties,hcl,env)")
 			flag.StringVar(g.genConfig, "gen", "", "")
 		}
-		g.configPath = flag.String("c", g.defaultConfigPath, "path for config file")
-		flag.StringVar(g.configPath, "config", g.defaultConfigPath, "")
+		g.configPath = &configPaths{paths: g.defaultConfigPaths}
+		flag.Var(g.configPath, "c", "path for config file, repeat to layer multiple files")
+		flag.Var(g.configPath, "config", "")
+		// a config field with the profile flag keeps it, the profile is then
+		// only selected by the env variable or the default
+		if f.Lookup("profile") == nil {
+			g.profile = flag.String("profile", g.profileDefault(), "config file profile merged on top of the base values (env "+g.profileEnv()+")")
+		} else {
+			p := g.profileDefault()
+			g.profile = &p
+		}
 	}
 
 	f.Usage = func() {
@@ -179,6 +226,7 @@ func (g *goConfig) Load() error {
 			}
 			fmt.Fprint(os.Stderr, s, "\n")
 		}
+		fmt.Fprint(os.Stderr, "sources from lowest to highest precedence: ", g.sourceOrder(), "\n")
 	}
 
 	if err := g.flags.Parse(); err != nil {
@@ -190,44 +238,68 @@ func (g *goConfig) Load() error {
 		os.Exit(0)
 	}
 
-	// load in lowest priority order: env -> .env file -> config file -> flag
-	if g.options.isEnabled(OptEnv) {
-		if err := env.New().Unmarshal(g.config); err != nil {
-			return err
-		}
+	// load in lowest priority order: env -> .env file -> configmap -> config file -> flag
+	// along with any added sources (see AddSource)
+	g.defaults = encode.Copy(g.config)
+	g.trackDefaults()
+	if err := g.loadSources(); err != nil {
+		return err
 	}
 
-	if g.options.isEnabled(OptEnvFile) {
-		if _, err := os.Stat(".env"); err == nil {
-			log.Println("loading .env file from working directory")
-			if err := env.LoadEnvFile(".env", g.config); err != nil {
-				return err
-			}
+	if g.genConfig != nil && *g.genConfig != "" {
+		err := g.generate(os.Stdout, *g.genConfig)
+		if err != nil {
+			log.Fatal(err)
 		}
+		os.Exit(0)
 	}
 
-	if g.options.isEnabled(OptFiles) && *g.configPath != "" {
-		if err := file.Load(*g.configPath, g.config); err != nil {
+	if g.options.isEnabled(OptShow) && *g.showConfig != "" {
+		if err := g.showValues(os.Stdout, string(*g.showConfig)); err != nil {
 			return err
 		}
+		os.Exit(0)
 	}
-	if g.options.isEnabled(OptFlag) {
-		if err := g.flags.Unmarshal(g.config); err != nil {
-			return err
-		}
+
+	// resolve secrets after -gen and -show so the values are never printed
+	return g.finish()
+}
+
+// Reload loads the sources again after Load or LoadOrDie so the config picks up
+// their changes (ie a changed HTTPSource document or a renewed VaultSource). The
+// secret references, required fields and Validator are then checked the same as Load.
+// The config is set back to its values from before Load and every source is loaded
+// again, so the provenance is the same as after Load and a value removed from all
+// sources returns to its default. The flags are not parsed again.
+//
+// Reload sets the values in place, so it must not be called while other
+// goroutines read the config.
+func (g *goConfig) Reload() error {
+	if g.flags == nil {
+		return errors.New("reload: config has not been loaded")
+	}
+	reflect.ValueOf(g.config).Elem().Set(reflect.ValueOf(encode.Copy(g.defaults)).Elem())
+	g.trackDefaults()
+	if err := g.loadSources(); err != nil {
+		return err
 	}
+	return g.finish()
+}
 
-	if g.options.isEnabled(OptGenConf) && *g.genConfig != "" {
-		err := file.Encode(os.Stdout, g.config, *g.genConfig)
-		if err != nil {
-			log.Fatal(err)
-		}
-		os.Exit(0)
+// Reload loads the sources of Load or LoadOrDie again (see goConfig.Reload).
+func Reload() error {
+	return defaultCfg.Reload()
+}
+
+// finish resolves secret references, checks the required fields and
+// validates the loaded config.
+func (g *goConfig) finish() error {
+	if err := g.resolve(); err != nil {
+		return err
 	}
 
-	if g.options.isEnabled(OptShow) && *g.showConfig {
-		spew.Dump(g.config)
-		os.Exit(0)
+	if err := g.checkRequired(); err != nil {
+		return err
 	}
 
 	// validate if struct implements validator interface
@@ -237,12 +309,39 @@ func (g *goConfig) Load() error {
 	return nil
 }
 
-// LoadFile loads configuration values from a file (yaml, toml, json)
-// into the struct configuration c.
+// generate writes a config template of the format (toml,json,jsonc,yaml,ini,properties,hcl,env) to w.
+// Fields that may not be set by the format's source (see the source tag) are left out.
+func (g *goConfig) generate(w io.Writer, format string) error {
+	if format != "env" {
+		c := encode.Restrict(g.config, func(allowed []string) bool {
+			return encode.SourceAllowed(allowed, string(SourceFile))
+		})
+		return file.Encode(w, c, format)
+	}
+	c := encode.Restrict(g.config, func(allowed []string) bool {
+		return encode.SourceAllowed(allowed, string(SourceEnv)) || encode.SourceAllowed(allowed, string(SourceEnvFile))
+	})
+	e := env.NewEncoder()
+	e.FileVars = g.options.isEnabled(OptEnvFileSuffix)
+	e.Prefix = g.envPrefix
+	b, err := e.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// LoadFile loads configuration values from a file (yaml, toml, json, jsonc, json5, ini, properties, hcl)
+// into the struct configuration c. If f is a directory every supported
+// file in the directory is loaded in lexical order.
 //
 // This would be used if we only want to parse a file and don't
 // want to use any other features. This is more or less what multi-config does.
 func LoadFile(f string, c interface{}) error {
+	if info, err := os.Stat(f); err == nil && info.IsDir() {
+		return file.LoadDir(f, c)
+	}
 	return file.Load(f, c)
 }
 
@@ -254,7 +353,7 @@ func LoadEnv(c interface{}) error {
 
 // LoadFlag is similar to LoadFile, but only checks flags.
 func LoadFlag(c interface{}) error {
-	f, err := flg.New(c)
+	f, err := flg.NewWithSeparator(c, defaultCfg.flagSep)
 	if err != nil {
 		return err
 	}
@@ -295,14 +394,55 @@ func (g *goConfig) Description(s string) *goConfig {
 	return g
 }
 
-// ConfigPath sets the default config file path used when -c or -config is not
-// provided. The path appears as the default in help output and is loaded
-// automatically. An explicit -c or -config flag overrides this value.
-func (g *goConfig) ConfigPath(path string) *goConfig {
-	g.defaultConfigPath = path
+// ConfigPath sets the default config file paths used when -c or -config is not
+// provided. The paths appear as the default in help output and are loaded
+// automatically in order. An explicit -c or -config flag overrides these values.
+func (g *goConfig) ConfigPath(paths ...string) *goConfig {
+	g.defaultConfigPaths = nil
+	for _, p := range paths {
+		if p != "" {
+			g.defaultConfigPaths = append(g.defaultConfigPaths, p)
+		}
+	}
 	return g
 }
 
+// Profile sets the default config file profile used when neither the -profile flag
+// nor the APP_PROFILE env variable is provided. The values in the profile section
+// of each config file are merged on top of the file's base values.
+func (g *goConfig) Profile(name string) *goConfig {
+	g.defaultProfile = name
+	return g
+}
+
+// FlagSeparator sets the separator used to join a nested struct's flag
+// prefix to its child flag names. Accepts "." (default) or "-".
+//
+//	-db.host=localhost // "."
+//	-db-host=localhost // "-"
+func (g *goConfig) FlagSeparator(sep string) *goConfig {
+	g.flagSep = sep
+	return g
+}
+
+// EnvPrefix is prepended to every env variable name (ie MYAPP_HOST)
+// for the process env, the .env file and -gen=env. Enable OptEnvUnprefixed
+// to fall back to the unprefixed name while migrating.
+func (g *goConfig) EnvPrefix(prefix string) *goConfig {
+	g.envPrefix = strings.TrimSuffix(prefix, "_")
+	return g
+}
+
+// envNames are the env variable names of the field with the EnvPrefix (see encode.EnvNames)
+func (g *goConfig) envNames(f encode.Field) []string {
+	return encode.EnvNames(g.envPrefix, f.Env, f.EnvAliases)
+}
+
+// envName is the env variable name with the EnvPrefix
+func (g *goConfig) envName(name string) string {
+	return encode.EnvPrefixed(g.envPrefix, name)
+}
+
 // Deprecated: Use Disable(OptEnv) instead
 // DisableEnv tells goConfig not to use environment variables
 func (g *goConfig) DisableEnv() *goConfig {
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -116,6 +120,16 @@ func TestGoConfig_Load(t *testing.T) {
 				Float32: 55,
 			},
 		},
+		"nested flag": {
+			Input: input{
+				config: testStruct{Value: 1},
+				flags:  []string{"-pointer.count=3"},
+			},
+			Expected: testStruct{
+				Value:   1,
+				Pointer: &childStruct{Count: trial.IntP(3)},
+			},
+		},
 		"env": {
 			Input: input{
 				config: testStruct{
@@ -352,7 +366,7 @@ func TestArgs_beforeLoad(t *testing.T) {
 
 func TestGoConfig_ConfigPath(t *testing.T) {
 	type input struct {
-		configPath string
+		configPath []string
 		flags      []string
 	}
 	fn := func(in input) (testStruct, error) {
@@ -367,12 +381,12 @@ func TestGoConfig_ConfigPath(t *testing.T) {
 			Float32: 12.3,
 		}
 		os.Args = append([]string{"go-config"}, in.flags...)
-		err := New(&c).ConfigPath(in.configPath).Disable(OptEnv | OptEnvFile).Load()
+		err := New(&c).ConfigPath(in.configPath...).Disable(OptEnv | OptEnvFile).Load()
 		return c, err
 	}
 	cases := trial.Cases[input, testStruct]{
 		"loads default path when -c not provided": {
-			Input: input{configPath: "test/test.toml"},
+			Input: input{configPath: []string{"test/test.toml"}},
 			Expected: testStruct{
 				Name:    "toml",
 				Time:    trial.TimeDay("2010-08-10"),
@@ -385,7 +399,60 @@ func TestGoConfig_ConfigPath(t *testing.T) {
 		},
 		"-c overrides default path": {
 			Input: input{
-				configPath: "test/test.toml",
+				configPath: []string{"test/test.toml"},
+				flags:      []string{"-c=test/test.yaml"},
+			},
+			Expected: testStruct{
+				Name:    "yaml",
+				Time:    trial.TimeDay("2010-08-10"),
+				Dura:    10 * time.Second,
+				Enable:  true,
+				Value:   10,
+				Uint:    2,
+				Float32: 12.3,
+			},
+		},
+		"layered -c flags": {
+			Input: input{
+				flags: []string{"-c=test/test.toml", "-c", "test/test.yaml"},
+			},
+			Expected: testStruct{
+				Name:    "yaml",
+				Time:    trial.TimeDay("2010-08-10"),
+				Dura:    10 * time.Second,
+				Enable:  true,
+				Value:   10,
+				Uint:    2,
+				Float32: 99.9,
+			},
+		},
+		"layered default paths": {
+			Input: input{configPath: []string{"test/test.yaml", "test/test.toml"}},
+			Expected: testStruct{
+				Name:    "toml",
+				Time:    trial.TimeDay("2010-08-10"),
+				Dura:    10 * time.Second,
+				Enable:  true,
+				Value:   10,
+				Uint:    2,
+				Float32: 99.9,
+			},
+		},
+		"conf.d directory": {
+			Input: input{flags: []string{"-c=test/test.toml", "-c=test/conf.d"}},
+			Expected: testStruct{
+				Name:    "override",
+				Time:    trial.TimeDay("2010-08-10"),
+				Dura:    10 * time.Second,
+				Enable:  true,
+				Value:   20,
+				Uint:    2,
+				Float32: 99.9,
+			},
+		},
+		"-c replaces all default paths": {
+			Input: input{
+				configPath: []string{"test/test.toml", "missing.toml"},
 				flags:      []string{"-c=test/test.yaml"},
 			},
 			Expected: testStruct{
@@ -399,7 +466,7 @@ func TestGoConfig_ConfigPath(t *testing.T) {
 			},
 		},
 		"empty default does not load file": {
-			Input: input{configPath: ""},
+			Input: input{configPath: []string{""}},
 			Expected: testStruct{
 				Dura:    time.Second,
 				Value:   1,
@@ -408,7 +475,7 @@ func TestGoConfig_ConfigPath(t *testing.T) {
 			},
 		},
 		"missing default path returns error": {
-			Input:     input{configPath: "missing.toml"},
+			Input:     input{configPath: []string{"missing.toml"}},
 			ShouldErr: true,
 		},
 	}
@@ -446,3 +513,611 @@ func TestOptions(t *testing.T) {
 		t.Errorf("Expected only flag bit off %b!=%b", 0b11011111, v.options)
 	}
 }
+
+func TestGoConfig_Required(t *testing.T) {
+	type db struct {
+		Username string `flag:"un" env:"UN" req:"true"`
+		Password string `flag:"pw" env:"PW"`
+	}
+	type tls struct {
+		Cert string `req:"true"`
+		Key  string
+	}
+	type reqStruct struct {
+		Host string `req:"true"`
+		Port int    `req:"true"`
+		DB   db     `flag:"db"`
+		TLS  *tls   // optional, only required when set
+	}
+	fn := func(args []string) (reqStruct, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		}()
+		c := reqStruct{Port: 8080}
+		os.Args = append([]string{"go-config"}, args...)
+		err := New(&c).Disable(OptEnvFile).Load()
+		return c, err
+	}
+	cases := trial.Cases[[]string, reqStruct]{
+		"all set": {
+			Input:    []string{"-host=localhost", "-db.un=admin"},
+			Expected: reqStruct{Host: "localhost", Port: 8080, DB: db{Username: "admin"}},
+		},
+		"missing": {
+			Input: []string{},
+			ExpectedErr: errors.New("missing required fields:\n" +
+				"\tHost (flag: -host, env: HOST, file: host)\n" +
+				"\tDB.Username (flag: -db.un, env: DB_UN, file: db.username)"),
+		},
+		"optional struct set": {
+			Input: []string{"-host=localhost", "-db.un=admin", "-tls.key=key.pem"},
+			ExpectedErr: errors.New("missing required fields:\n" +
+				"\tTLS.Cert (flag: -tls.cert, env: TLS_CERT, file: tls.cert)"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_Profile(t *testing.T) {
+	type input struct {
+		profile string
+		env     string
+		flags   []string
+	}
+	fn := func(in input) (testStruct, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			os.Unsetenv(ProfileEnv)
+		}()
+		if in.env != "" {
+			os.Setenv(ProfileEnv, in.env)
+		}
+		c := testStruct{}
+		os.Args = append([]string{"go-config", "-c=test/profile.toml"}, in.flags...)
+		err := New(&c).Profile(in.profile).Disable(OptEnv | OptEnvFile).Load()
+		return c, err
+	}
+	cases := trial.Cases[input, testStruct]{
+		"no profile": {
+			Input:    input{},
+			Expected: testStruct{Name: "base", Value: 10},
+		},
+		"default profile": {
+			Input:    input{profile: "prod"},
+			Expected: testStruct{Name: "prod", Value: 10},
+		},
+		"env profile": {
+			Input:    input{profile: "prod", env: "stage"},
+			Expected: testStruct{Name: "stage", Value: 20},
+		},
+		"flag profile": {
+			Input:    input{env: "stage", flags: []string{"-profile=prod"}},
+			Expected: testStruct{Name: "prod", Value: 10},
+		},
+		"profile in one layer": {
+			Input:    input{flags: []string{"-c=test/test.toml", "-profile=prod"}},
+			Expected: testStruct{Name: "toml", Value: 10, Enable: true, Time: trial.TimeDay("2010-08-10"), Float32: 99.9, Dura: 10 * time.Second},
+		},
+		"unknown profile": {
+			Input:       input{flags: []string{"-profile=dev"}},
+			ExpectedErr: errors.New(`profile "dev" not found in the config files`),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_ProfileField(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		os.Unsetenv(ProfileEnv)
+	}()
+	os.Setenv(ProfileEnv, "stage")
+	c := struct {
+		Name    string
+		Value   int
+		Profile string
+	}{}
+	os.Args = []string{"go-config", "-c=test/profile.toml", "-profile=blue"}
+	if err := New(&c).Disable(OptEnv | OptEnvFile).Load(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile != "blue" || c.Name != "stage" || c.Value != 20 {
+		t.Errorf("got %+v, want the blue profile field and the stage file profile", c)
+	}
+}
+
+func TestGoConfig_ReloadResolve(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	}()
+	os.Unsetenv("PASSWORD")
+	os.Args = []string{"go-config", "-password=upper://hunter2"}
+	c := struct{ Password string }{}
+	g := New(&c).Disable(OptEnvFile).RegisterResolver("upper", ResolverFunc(func(ref string) (string, error) {
+		return strings.ToUpper(ref), nil
+	}))
+	if err := g.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := g.Reload(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Password != "HUNTER2" {
+		t.Errorf("password %q != HUNTER2", c.Password)
+	}
+	p, _ := g.ProvenanceOf("Password")
+	if p.Kind != SourceFlag || p.Value != "upper://hunter2" || len(p.Overridden) != 1 {
+		t.Errorf("provenance %v", p)
+	}
+}
+
+func TestGoConfig_Resolve(t *testing.T) {
+	type secrets struct {
+		Password string
+		Token    *string
+		Keys     []string
+		Plain    string
+	}
+	fn := func(args []string) (secrets, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			os.Unsetenv("GO_CONFIG_TOKEN")
+		}()
+		os.Setenv("GO_CONFIG_TOKEN", "envtoken")
+		c := secrets{Plain: "http://localhost", Keys: []string{"exec://echo a  b", "upper://c"}}
+		os.Args = append([]string{"go-config"}, args...)
+		err := New(&c).Disable(OptEnv|OptEnvFile).
+			RegisterResolver("file", FileResolver).
+			RegisterResolver("env", EnvResolver).
+			RegisterResolver("exec", ExecResolver).
+			RegisterResolver("upper", ResolverFunc(func(ref string) (string, error) {
+				return strings.ToUpper(ref), nil
+			})).Load()
+		return c, err
+	}
+	token := "envtoken"
+	cases := trial.Cases[[]string, secrets]{
+		"resolved": {
+			Input: []string{"-password=file://test/secrets/db_pw", "-token=env://GO_CONFIG_TOKEN"},
+			Expected: secrets{
+				Password: "filepw",
+				Token:    &token,
+				Keys:     []string{"a b", "C"},
+				Plain:    "http://localhost",
+			},
+		},
+		"errors": {
+			Input: []string{"-password=file://test/secrets/missing", "-token=env://GO_CONFIG_MISSING", "-plain=exec://false"},
+			ExpectedErr: errors.New("secret reference errors:\n" +
+				"\tPassword \"file://test/secrets/missing\": open test/secrets/missing: no such file or directory\n" +
+				"\tToken \"env://GO_CONFIG_MISSING\": env variable GO_CONFIG_MISSING is not set\n" +
+				"\tPlain \"exec://false\": exit status 1"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_ResolveUnregistered(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	}()
+	os.Args = []string{"go-config", "-path=file:///etc/hosts", "-cmd=exec://false"}
+	c := struct{ Path, Cmd string }{}
+	if err := New(&c).Disable(OptEnv | OptEnvFile).Load(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Path != "file:///etc/hosts" || c.Cmd != "exec://false" {
+		t.Errorf("references should not be resolved by default %+v", c)
+	}
+}
+
+func TestGoConfig_EnvFileSuffix(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		os.Unsetenv("PASSWORD_FILE")
+	}()
+	os.Setenv("PASSWORD_FILE", "test/secrets/db_pw")
+	os.Args = []string{"go-config"}
+	c := struct{ Password string }{}
+	g := New(&c).SetOptions(OptEnv).Enable(OptEnvFileSuffix)
+	if err := g.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Password != "filepw" {
+		t.Errorf("password %q != filepw", c.Password)
+	}
+	if p, _ := g.ProvenanceOf("Password"); p.Detail != "PASSWORD_FILE" {
+		t.Errorf("detail %q != PASSWORD_FILE", p.Detail)
+	}
+}
+
+func TestGoConfig_ConfigMap(t *testing.T) {
+	type db struct {
+		Username string `env:"UN"`
+		Port     int
+	}
+	type cmStruct struct {
+		Host string
+		DB   db
+	}
+	fn := func(flags []string) (cmStruct, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			os.Unsetenv("DB_UN")
+		}()
+		os.Setenv("DB_UN", "envuser")
+		c := cmStruct{}
+		os.Args = append([]string{"go-config"}, flags...)
+		err := New(&c).ConfigMap("test/configmap").Disable(OptEnvFile).Load()
+		return c, err
+	}
+	cases := trial.Cases[[]string, cmStruct]{
+		"configmap over env": {
+			Input:    []string{},
+			Expected: cmStruct{Host: "cmhost", DB: db{Username: "cmuser", Port: 9}},
+		},
+		"flag over configmap": {
+			Input:    []string{"-host=flaghost"},
+			Expected: cmStruct{Host: "flaghost", DB: db{Username: "cmuser", Port: 9}},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_Jsonc(t *testing.T) {
+	fn := func(opts Options) (testStruct, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		}()
+		c := testStruct{}
+		os.Args = []string{"go-config", "-c=test/comments.json"}
+		err := New(&c).Disable(OptEnv | OptEnvFile).Enable(opts).Load()
+		return c, err
+	}
+	cases := trial.Cases[Options, testStruct]{
+		"strict json": {
+			Input:     0,
+			ShouldErr: true,
+		},
+		"jsonc enabled": {
+			Input:    OptJsonc,
+			Expected: testStruct{Name: "comments", Value: 10},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_Hcl(t *testing.T) {
+	type input struct {
+		args       []string
+		opts       Options
+		enable     Options
+		setOptions bool
+	}
+	fn := func(in input) (testStruct, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		}()
+		c := testStruct{}
+		os.Args = append([]string{"go-config"}, in.args...)
+		g := New(&c).Disable(OptEnv | OptEnvFile)
+		if in.setOptions {
+			g.SetOptions(in.opts)
+		}
+		err := g.Enable(in.enable).Load()
+		return c, err
+	}
+	cases := trial.Cases[input, testStruct]{
+		"disabled by default": {
+			Input:       input{args: []string{"-c=test/test.hcl"}},
+			ExpectedErr: errors.New("hcl files are disabled"),
+		},
+		"enabled": {
+			Input:    input{args: []string{"-c=test/test.hcl"}, enable: OptHcl},
+			Expected: testStruct{Name: "hcl", Value: 10, Enable: true, Time: trial.TimeDay("2010-08-10"), Float64: 99.9, Dura: 10 * time.Second},
+		},
+		"files without hcl": {
+			Input:    input{args: []string{"-c=test/test.toml"}, setOptions: true, opts: OptToml | OptYaml | OptJson},
+			Expected: testStruct{Name: "toml", Value: 10, Enable: true, Time: trial.TimeDay("2010-08-10"), Float32: 99.9, Dura: 10 * time.Second},
+		},
+		"gen without files": {
+			Input:    input{setOptions: true, opts: OptGenConf | OptFlag, args: []string{"-name=flag"}},
+			Expected: testStruct{Name: "flag"},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_ConfigEnv(t *testing.T) {
+	fn := func(env map[string]string) (testStruct, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			for k := range env {
+				os.Unsetenv(k)
+			}
+		}()
+		for k, v := range env {
+			os.Setenv(k, v)
+		}
+		c := testStruct{}
+		os.Args = []string{"go-config", "-c=test/test.toml"}
+		err := New(&c).ConfigEnv("APP_CONFIG").Disable(OptEnv | OptEnvFile).Load()
+		return c, err
+	}
+	base := testStruct{Name: "toml", Value: 10, Enable: true, Float32: 99.9, Dura: 10 * time.Second, Time: trial.TimeDay("2010-08-10")}
+	cases := trial.Cases[map[string]string, testStruct]{
+		"file only": {
+			Input:    map[string]string{},
+			Expected: base,
+		},
+		"json over file": {
+			Input: map[string]string{"APP_CONFIG_JSON": `{"name":"json"}`},
+			Expected: func() testStruct {
+				c := base
+				c.Name = "json"
+				return c
+			}(),
+		},
+		"base64 yaml": {
+			Input: map[string]string{"APP_CONFIG_YAML": "base64:bmFtZTogeWFtbAp2YWx1ZTogMjAK"}, // name: yaml\nvalue: 20
+			Expected: func() testStruct {
+				c := base
+				c.Name, c.Value = "yaml", 20
+				return c
+			}(),
+		},
+		"invalid base64": {
+			Input:     map[string]string{"APP_CONFIG_JSON": "base64:!!"},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_ConfigEnvNoFiles(t *testing.T) {
+	fn := func(g func(*goConfig)) (testStruct, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			os.Unsetenv("APP_CONFIG_JSON")
+		}()
+		os.Setenv("APP_CONFIG_JSON", `{"name":"json"}`)
+		c := testStruct{}
+		os.Args = []string{"go-config"}
+		cfg := New(&c).ConfigEnv("APP_CONFIG").Disable(OptEnv | OptEnvFile)
+		g(cfg)
+		err := cfg.Load()
+		return c, err
+	}
+	cases := trial.Cases[func(*goConfig), testStruct]{
+		"files disabled": {
+			Input:       func(g *goConfig) { g.Disable(OptFiles) },
+			ExpectedErr: errors.New("config env APP_CONFIG: the file source is not loaded"),
+		},
+		"file not in precedence": {
+			Input:       func(g *goConfig) { g.Precedence(SourceEnv, SourceFlag) },
+			ExpectedErr: errors.New("config env APP_CONFIG: the file source is not loaded"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_EnvPrefix(t *testing.T) {
+	type db struct {
+		Username string `env:"UN" req:"true"`
+	}
+	type prefixStruct struct {
+		Host string
+		DB   db
+	}
+	type input struct {
+		opts Options
+		env  map[string]string
+	}
+	type output struct {
+		Config prefixStruct
+		Detail string
+	}
+	fn := func(in input) (output, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			for k := range in.env {
+				os.Unsetenv(k)
+			}
+		}()
+		for k, v := range in.env {
+			os.Setenv(k, v)
+		}
+		c := prefixStruct{}
+		os.Args = []string{"go-config"}
+		g := New(&c).EnvPrefix("MYAPP").Disable(OptEnvFile).Enable(in.opts)
+		if err := g.Load(); err != nil {
+			return output{}, err
+		}
+		p, _ := g.ProvenanceOf("Host")
+		return output{Config: c, Detail: p.Detail}, nil
+	}
+	cases := trial.Cases[input, output]{
+		"prefixed": {
+			Input:    input{env: map[string]string{"MYAPP_HOST": "myhost", "MYAPP_DB_UN": "admin", "HOST": "other"}},
+			Expected: output{Config: prefixStruct{Host: "myhost", DB: db{Username: "admin"}}, Detail: "MYAPP_HOST"},
+		},
+		"unprefixed ignored": {
+			Input: input{env: map[string]string{"HOST": "other", "DB_UN": "admin"}},
+			ExpectedErr: errors.New("missing required fields:\n" +
+				"\tDB.Username (flag: -db.username, env: MYAPP_DB_UN, file: db.username)"),
+		},
+		"unprefixed fallback": {
+			Input:    input{opts: OptEnvUnprefixed, env: map[string]string{"HOST": "other", "DB_UN": "admin"}},
+			Expected: output{Config: prefixStruct{Host: "other", DB: db{Username: "admin"}}, Detail: "HOST"},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_EnvFileConfigPrefix(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	}()
+	path := filepath.Join(t.TempDir(), "app.env")
+	if err := os.WriteFile(path, []byte("MYAPP_NAME=prefixed\nNAME=bare\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Args = []string{"go-config", "-c=" + path}
+	c := testStruct{}
+	g := New(&c).EnvPrefix("MYAPP").Disable(OptEnv | OptEnvFile)
+	if err := g.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "prefixed" {
+		t.Errorf("name: got %q, want %q", c.Name, "prefixed")
+	}
+	p, _ := g.ProvenanceOf("Name")
+	if want := path + ":1"; p.Detail != want {
+		t.Errorf("detail: got %q, want %q", p.Detail, want)
+	}
+}
+
+func TestGoConfig_EnvAliases(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		os.Unsetenv("PORT")
+	}()
+	os.Setenv("PORT", "9000")
+	os.Args = []string{"go-config"}
+	c := struct {
+		Port int `env:"HTTP_PORT|PORT"`
+	}{}
+	g := New(&c).EnvPrefix("MYAPP").Disable(OptEnvFile)
+	if err := g.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != 9000 {
+		t.Errorf("port %d != 9000", c.Port)
+	}
+	if p, _ := g.ProvenanceOf("Port"); p.Detail != "PORT" {
+		t.Errorf("detail %q != PORT", p.Detail)
+	}
+}
+
+func TestGoConfig_SourceTag(t *testing.T) {
+	type restricted struct {
+		Name     string
+		Password string `source:"env"`
+		Token    string `source:"file,flag"`
+	}
+	type input struct {
+		flags    []string
+		env      map[string]string
+		defaults restricted
+	}
+	fn := func(in input) (restricted, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			for k := range in.env {
+				os.Unsetenv(k)
+			}
+		}()
+		for k, v := range in.env {
+			os.Setenv(k, v)
+		}
+		c := in.defaults
+		os.Args = append([]string{"go-config"}, in.flags...)
+		err := New(&c).Disable(OptEnvFile).Load()
+		return c, err
+	}
+	cases := trial.Cases[input, restricted]{
+		"allowed": {
+			Input:    input{flags: []string{"-token=abc"}, env: map[string]string{"PASSWORD": "secret"}},
+			Expected: restricted{Password: "secret", Token: "abc"},
+		},
+		"forbidden env": {
+			Input:       input{env: map[string]string{"TOKEN": "abc"}},
+			ExpectedErr: errors.New("Token may not be set from env TOKEN (allowed sources: file,flag)"),
+		},
+		"forbidden file": {
+			Input:       input{flags: []string{"-c=test/secrets/password.toml"}},
+			ExpectedErr: errors.New("Password may not be set from file test/secrets/password.toml:1 (allowed sources: env)"),
+		},
+		"nested key": {
+			Input:    input{flags: []string{"-c=test/secrets/nested_password.toml"}},
+			Expected: restricted{},
+		},
+		"forbidden env same value": {
+			Input:       input{env: map[string]string{"TOKEN": "abc"}, defaults: restricted{Token: "abc"}},
+			ExpectedErr: errors.New("Token may not be set from env TOKEN (allowed sources: file,flag)"),
+		},
+		"forbidden file same value": {
+			Input:       input{flags: []string{"-c=test/secrets/password.toml"}, defaults: restricted{Password: "infile"}},
+			ExpectedErr: errors.New("Password may not be set from file test/secrets/password.toml:1 (allowed sources: env)"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_generate(t *testing.T) {
+	type restricted struct {
+		Name     string
+		Password string `source:"env"`
+		Token    string `source:"file,flag"`
+	}
+	fn := func(format string) (string, error) {
+		var b strings.Builder
+		err := New(&restricted{Name: "app"}).generate(&b, format)
+		return b.String(), err
+	}
+	cases := trial.Cases[string, string]{
+		"env": {
+			Input:    "env",
+			Expected: "NAME=app\nPASSWORD=\"\"\n",
+		},
+		"toml": {
+			Input:    "toml",
+			Expected: "Name = \"app\"\nToken = \"\"\n",
+		},
+		"ini": {
+			Input:    "ini",
+			Expected: "name = app\ntoken = \"\"\n",
+		},
+		"properties": {
+			Input:    "properties",
+			Expected: "name=app\ntoken=\n",
+		},
+		"jsonc": {
+			Input:    "jsonc",
+			Expected: "{\n  \"Name\": \"app\",\n  \"Token\": \"\"\n}\n",
+		},
+		"hcl": {
+			Input:    "hcl",
+			Expected: "name = \"app\"\ntoken = \"\"\n",
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_SelfReferential(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		os.Unsetenv("NAME")
+	}()
+	os.Setenv("NAME", "env")
+	os.Args = []string{"go-config", "-name=flag"}
+	c := node{}
+	g := New(&c).Disable(OptEnvFile)
+	if err := g.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "flag" || c.Next != nil {
+		t.Errorf("unexpected config %+v", c)
+	}
+	for _, format := range []string{"env", "toml", "json", "jsonc", "yaml", "ini", "properties", "hcl"} {
+		if err := g.generate(io.Discard, format); err != nil {
+			t.Errorf("generate %s: %v", format, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"path/filepath"
+
+	"github.com/hydronica/go-config/internal/encode"
+	"github.com/hydronica/go-config/internal/encode/env"
+)
+
+// ConfigMap sets the directories of mounted Kubernetes ConfigMap or Secret
+// volumes. Each file name is a key using the env variable naming rules
+// (dotted names for nested fields, ie db.host or DB_HOST) and the file
+// contents are the value. Directories are loaded in order after the env
+// variables and before config files.
+func (g *goConfig) ConfigMap(dirs ...string) *goConfig {
+	g.configMapDirs = nil
+	for _, d := range dirs {
+		if d != "" {
+			g.configMapDirs = append(g.configMapDirs, d)
+		}
+	}
+	return g
+}
+
+// loadConfigMaps loads each ConfigMap directory in order.
+func (g *goConfig) loadConfigMaps() error {
+	for _, dir := range g.configMapDirs {
+		dir := dir
+		files, err := env.ConfigMapFiles(dir)
+		if err != nil {
+			return err
+		}
+		detail := func(f encode.Field) (string, bool) {
+			if path, ok := files[f.Env]; ok {
+				return path, true
+			}
+			return filepath.Join(dir, f.Env), false
+		}
+		if err := g.track(SourceConfigMap, detail, func() error {
+			return env.LoadConfigMap(dir, g.config)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+	"github.com/hydronica/go-config/internal/encode/file"
+)
+
+// ProfileEnv is the env variable used to select the config file
+// profile when the -profile flag is not provided. The APP prefix
+// is replaced by the EnvPrefix when set (ie MYAPP_PROFILE).
+const ProfileEnv = "APP_PROFILE"
+
+// configPaths is the -c/-config flag value. The flag may be repeated
+// to layer multiple config files which are loaded in order.
+// The first explicit flag replaces the default paths.
+type configPaths struct {
+	paths []string
+	set   bool
+}
+
+func (c *configPaths) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(c.paths, ",")
+}
+
+func (c *configPaths) Set(s string) error {
+	if !c.set {
+		c.paths, c.set = nil, true
+	}
+	if s != "" {
+		c.paths = append(c.paths, s)
+	}
+	return nil
+}
+
+// loadFiles loads each config file in order. Later files override the values
+// of earlier files field by field and each file may be a different format.
+// A directory path loads every supported file within it in lexical order (ie conf.d).
+//
+// The selected profile only needs to be in one of the files or ConfigEnv documents.
+func (g *goConfig) loadFiles() error {
+	paths, err := g.expandDirs(g.configPath.paths)
+	if err != nil {
+		return err
+	}
+	missing := 0 // files and documents without the profile
+	for _, path := range paths {
+		path := path
+		detail := g.fileDetail(path, g.fileKey(path))
+		if err := g.track(SourceFile, detail, func() error {
+			return countMissingProfile(&missing, g.fileLoader().LoadProfile(path, g.profileName(), g.config))
+		}); err != nil {
+			return err
+		}
+	}
+	docs, docsMissing, err := g.loadConfigEnv()
+	if err != nil {
+		return err
+	}
+	missing += docsMissing
+	if missing > 0 && missing == len(paths)+docs {
+		return fmt.Errorf("profile %q not found in the config files", g.profileName())
+	}
+	return nil
+}
+
+// ConfigEnv enables loading a whole config document from the env variables
+// <name>_JSON, <name>_YAML or <name>_TOML (ie APP_CONFIG_JSON). The document is
+// decoded like a config file after the config files. A value with the "base64:"
+// prefix is base64 decoded first. Load returns an error if the file source is
+// not loaded (ie OptFiles is disabled or SourceFile is not in the Precedence).
+func (g *goConfig) ConfigEnv(name string) *goConfig {
+	g.configEnv = name
+	return g
+}
+
+// checkConfigEnv returns an error if ConfigEnv is set but the file source
+// that loads its documents is not loaded (see OptFiles and Precedence).
+func (g *goConfig) checkConfigEnv(sources []source) error {
+	if g.configEnv == "" {
+		return nil
+	}
+	for _, s := range sources {
+		if s.kind == SourceFile && s.enabled {
+			return nil
+		}
+	}
+	return fmt.Errorf("config env %s: the %s source is not loaded (see OptFiles and Precedence)", g.configEnv, SourceFile)
+}
+
+// configEnvFormats are the env variable suffixes and format of ConfigEnv documents
+var configEnvFormats = []struct{ suffix, format string }{
+	{"_JSON", "json"},
+	{"_YAML", "yaml"},
+	{"_TOML", "toml"},
+}
+
+// countMissingProfile counts an ErrProfileNotFound as the values are still loaded
+// and returns any other error.
+func countMissingProfile(missing *int, err error) error {
+	if errors.Is(err, file.ErrProfileNotFound) {
+		*missing++
+		return nil
+	}
+	return err
+}
+
+// loadConfigEnv loads the config documents from the ConfigEnv env variables.
+// It returns the number of documents loaded and how many did not have the profile.
+func (g *goConfig) loadConfigEnv() (docs, missing int, err error) {
+	if g.configEnv == "" {
+		return 0, 0, nil
+	}
+	for _, f := range configEnvFormats {
+		name := g.configEnv + f.suffix
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		b := []byte(v)
+		if strings.HasPrefix(v, "base64:") {
+			var err error
+			if b, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(v, "base64:")); err != nil {
+				return docs, missing, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		docs++
+		format := f.format
+		detail := func(encode.Field) (string, bool) { return name, false }
+		if err := g.track(SourceFile, detail, func() error {
+			return countMissingProfile(&missing, g.fileLoader().LoadBytes(b, format, g.profileName(), g.config))
+		}); err != nil {
+			return docs, missing, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return docs, missing, nil
+}
+
+// fileLoader for the config files and ConfigEnv documents
+func (g *goConfig) fileLoader() file.Loader {
+	return file.Loader{
+		JSONC: g.options.isEnabled(OptJsonc),
+		NoHCL: !g.options.isEnabled(OptHcl),
+		Env:   g.envDecoder(),
+	}
+}
+
+// expandDirs replaces each directory in paths with the
+// supported config files it contains in lexical order.
+func (g *goConfig) expandDirs(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		if info, err := os.Stat(p); err != nil || !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		dirFiles, err := g.fileLoader().DirFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+// profileName is the selected config file profile, empty when files are disabled.
+func (g *goConfig) profileName() string {
+	if g.profile == nil {
+		return ""
+	}
+	return *g.profile
+}
+
+// profileDefault is the profile from the env variable or Profile.
+func (g *goConfig) profileDefault() string {
+	if p := os.Getenv(g.profileEnv()); p != "" {
+		return p
+	}
+	return g.defaultProfile
+}
+
+// profileEnv is the ProfileEnv with the EnvPrefix
+func (g *goConfig) profileEnv() string {
+	if g.envPrefix == "" {
+		return ProfileEnv
+	}
+	return g.envName("PROFILE")
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hydronica/go-config/internal/encode/file"
+)
+
+// HTTPSource is a Source that loads a config document (json, yaml, toml or ini)
+// from a URL. The document is decoded the same as a config file (see LoadFile)
+// with the format chosen from the Content-Type header or the URL extension,
+// except the include key is not followed and ${VAR} references are not expanded
+// so the server cannot read local files or env variables.
+// When added with AddSource the config's file options (ie OptJsonc) and
+// selected profile are used. The document does not need to have the profile.
+//
+// Each document is saved to a local cache file. Requests send the ETag of the
+// cached document with If-None-Match so an unchanged document is not downloaded
+// again, and the cached document is used when the endpoint is unreachable.
+type HTTPSource struct {
+	URL    string
+	Client *http.Client
+
+	// CachePath is the local copy of the document without the extension.
+	// Defaults to a file named by the URL's hash in the user cache directory.
+	CachePath string
+
+	etag  string
+	cache string // path of the cached document with the format extension
+
+	loader  file.Loader // file options of the config (see setFileOptions)
+	profile string
+}
+
+// fileSource is implemented by sources that decode config documents
+// with the config's file options and profile.
+type fileSource interface {
+	setFileOptions(l file.Loader, profile string)
+}
+
+func (h *HTTPSource) setFileOptions(l file.Loader, profile string) {
+	h.loader, h.profile = l, profile
+}
+
+// NewHTTPSource creates a HTTPSource for the url with a 10 second timeout.
+//
+//	config.New(&c).AddSource(config.NewHTTPSource("https://config.local/myapp.yaml"), config.PriorityFile)
+func NewHTTPSource(url string) *HTTPSource {
+	return &HTTPSource{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Source
+func (h *HTTPSource) Name() string { return "http" }
+
+// Location implements Locator
+func (h *HTTPSource) Location() string { return h.URL }
+
+// Unmarshal fetches the document (see Fetch) and decodes it into v.
+func (h *HTTPSource) Unmarshal(v interface{}) error {
+	if _, err := h.Fetch(); err != nil {
+		return err
+	}
+	b, err := os.ReadFile(h.cache)
+	if err != nil {
+		return err
+	}
+	format := strings.TrimPrefix(filepath.Ext(h.cache), ".")
+	l := h.loader
+	l.NoInterpolate = true
+	err = l.LoadBytes(b, format, h.profile, v)
+	if errors.Is(err, file.ErrProfileNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Fetch downloads the document to the cache if it has changed since the last Fetch.
+// changed is false when the server responds with 304 Not Modified or the endpoint
+// is unreachable and the cached document is used instead. Fetch may be polled to
+// check for changes and the config reloaded with goConfig.Reload when changed.
+func (h *HTTPSource) Fetch() (changed bool, err error) {
+	base := h.cachePath()
+	if h.cache == "" {
+		h.cache = cachedFile(base)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, h.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	if h.etag != "" && h.cache != "" {
+		req.Header.Set("If-None-Match", h.etag)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+		resp.Body.Close()
+		err = fmt.Errorf("status %s", resp.Status)
+	}
+	if err != nil {
+		if h.cache == "" {
+			return false, fmt.Errorf("http source %s: %w", h.URL, err)
+		}
+		log.Printf("http source %s: %v using cached %s", h.URL, err, h.cache)
+		return false, nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return false, nil
+	default:
+		return false, fmt.Errorf("http source %s: status %s", h.URL, resp.Status)
+	}
+
+	ext, err := h.format(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false, err
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("http source %s: %w", h.URL, err)
+	}
+	if err := writeCache(base+ext, b); err != nil {
+		return false, err
+	}
+	h.cache, h.etag = base+ext, resp.Header.Get("ETag")
+	return true, nil
+}
+
+// format returns the file extension of the document from the
+// content type or the URL path when the content type is unknown.
+func (h *HTTPSource) format(contentType string) (string, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		return ".json", nil
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return ".yaml", nil
+	case "application/toml", "text/toml":
+		return ".toml", nil
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return "", err
+	}
+	ext := path.Ext(u.Path)
+	if ext == ".env" || !h.loader.Supported(ext) {
+		return "", fmt.Errorf("http source %s: unknown format %q", h.URL, contentType)
+	}
+	return ext, nil
+}
+
+// cachePath of the document without the extension
+func (h *HTTPSource) cachePath() string {
+	if h.CachePath != "" {
+		return h.CachePath
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	sum := sha256.Sum256([]byte(h.URL))
+	return filepath.Join(dir, "go-config", hex.EncodeToString(sum[:8]))
+}
+
+// cachedFile returns an existing cached document for the base path
+func cachedFile(base string) string {
+	for _, ext := range []string{".json", ".jsonc", ".json5", ".yaml", ".yml", ".toml", ".ini", ".properties", ".hcl"} {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// writeCache replaces the cached document so that a partial write is never read.
+func writeCache(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	// remove documents of other formats
+	if old := cachedFile(strings.TrimSuffix(path, filepath.Ext(path))); old != "" && old != path {
+		os.Remove(old)
+	}
+	return os.Rename(tmp, path)
+}
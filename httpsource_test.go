@@ -0,0 +1,168 @@
+package config
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestHTTPSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.yaml": // format from the extension
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Write([]byte("name: yaml\nvalue: 10\n"))
+		case "/config": // format from the content type
+			w.Header().Set("Content-Type", "application/toml; charset=utf-8")
+			w.Write([]byte("name = \"toml\"\n"))
+		case "/unknown":
+			w.Write([]byte("name: yaml\n"))
+		case "/include.toml": // includes are not followed
+			w.Write([]byte("include = [\"/etc/hostname\"]\nname = \"include\"\n"))
+		case "/interpolate.toml": // the local env is not read
+			w.Write([]byte("name = \"http://host/?k=${GO_CONFIG_SECRET}\"\n"))
+		case "/profile.json":
+			w.Write([]byte("{\n// comment\n\"name\": \"base\", \"profiles\": {\"prod\": {\"name\": \"prod\"}},\n}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	type output struct {
+		Config  testStruct
+		Changed bool
+	}
+	os.Setenv("GO_CONFIG_SECRET", "s3cr3t")
+	defer os.Unsetenv("GO_CONFIG_SECRET")
+	fn := func(path string) (output, error) {
+		h := NewHTTPSource(srv.URL + path)
+		h.CachePath = filepath.Join(t.TempDir(), "config")
+		c := testStruct{}
+		if err := h.Unmarshal(&c); err != nil {
+			return output{}, err
+		}
+		changed, err := h.Fetch()
+		return output{Config: c, Changed: changed}, err
+	}
+	cases := trial.Cases[string, output]{
+		"yaml extension": {
+			Input:    "/config.yaml",
+			Expected: output{Config: testStruct{Name: "yaml", Value: 10}, Changed: false}, // 304 on second fetch
+		},
+		"content type": {
+			Input:    "/config",
+			Expected: output{Config: testStruct{Name: "toml"}, Changed: true},
+		},
+		"no includes": {
+			Input:    "/include.toml",
+			Expected: output{Config: testStruct{Name: "include"}, Changed: true},
+		},
+		"no interpolation": {
+			Input:    "/interpolate.toml",
+			Expected: output{Config: testStruct{Name: "http://host/?k=${GO_CONFIG_SECRET}"}, Changed: true},
+		},
+		"unknown format": {
+			Input:     "/unknown",
+			ShouldErr: true,
+		},
+		"not found": {
+			Input:     "/missing.json",
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+
+	// file options and profile of the config
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	}()
+	os.Args = []string{"go-config", "-profile=prod"}
+	h := NewHTTPSource(srv.URL + "/profile.json")
+	h.CachePath = filepath.Join(t.TempDir(), "config")
+	c := testStruct{}
+	err := New(&c).Disable(OptEnv|OptEnvFile).Enable(OptJsonc).AddSource(h, PriorityFile).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "prod" {
+		t.Errorf("profile name %q != prod", c.Name)
+	}
+
+	// fallback to the cached document when the server is unreachable
+	h = NewHTTPSource(srv.URL + "/config.yaml")
+	h.CachePath = filepath.Join(t.TempDir(), "config")
+	if _, err := h.Fetch(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+	c = testStruct{}
+	if err := h.Unmarshal(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "yaml" {
+		t.Errorf("cached name %q != yaml", c.Name)
+	}
+
+	// no cached document
+	h.CachePath = filepath.Join(t.TempDir(), "config")
+	h.cache = ""
+	if err := h.Unmarshal(&c); err == nil {
+		t.Error("expected error without a cached document")
+	}
+}
+
+func TestHTTPSource_reload(t *testing.T) {
+	doc, etag := "name: v1\n", `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(doc))
+	}))
+	defer srv.Close()
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	}()
+	os.Args = []string{"go-config"}
+
+	h := NewHTTPSource(srv.URL + "/config.yaml")
+	h.CachePath = filepath.Join(t.TempDir(), "config")
+	c := testStruct{Value: 5}
+	cfg := New(&c).Disable(OptEnv|OptEnvFile).AddSource(h, PriorityFile)
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := h.Fetch(); err != nil || changed {
+		t.Fatalf("unchanged document: changed=%v err=%v", changed, err)
+	}
+
+	doc, etag = "name: v2\n", `"v2"`
+	if changed, err := h.Fetch(); err != nil || !changed {
+		t.Fatalf("changed document: changed=%v err=%v", changed, err)
+	}
+	if err := cfg.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	exp := testStruct{Name: "v2", Value: 5}
+	if eq, diff := trial.Equal(c, exp); !eq {
+		t.Error(diff)
+	}
+	if p, _ := cfg.ProvenanceOf("Name"); p.Kind != "http" || len(p.Overridden) != 1 {
+		t.Errorf("provenance %v", p)
+	}
+
+	if err := New(&testStruct{}).Reload(); err == nil {
+		t.Error("expected error when reloading before Load")
+	}
+}
@@ -6,8 +6,39 @@ const (
 	DescTag   = "comment"
 	FormatTag = "format"
 	ConfigTag = "config"
+	ReqTag    = "req"
+	ShowTag   = "show"
+	SourceTag = "source"
 )
 
+// EnvAliasSep separates the alternative names of an env tag (ie `env:"HTTP_PORT|PORT"`).
+// The first name is the primary name and the others are tried in order.
+// Aliases are full env names that are not prefixed by their parent structs
+// or the EnvPrefix (see EnvNames).
+const EnvAliasSep = "|"
+
+// EnvNames returns the env names of a field in the order they are looked up,
+// the primary name with the prefix followed by the aliases as is.
+// ie: prefix MYAPP and `env:"HTTP_PORT|PORT"` is MYAPP_HTTP_PORT, PORT
+func EnvNames(prefix, name string, aliases []string) []string {
+	return append([]string{EnvPrefixed(prefix, name)}, aliases...)
+}
+
+// EnvPrefixed prepends the prefix to name separated by an underscore.
+// An empty name takes on the prefix so that it can passthrough
+// if the type is a struct or pointer struct (omitprefix).
+func EnvPrefixed(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	// An existing underscore means there will be 2 underscores. The user is given almost full reign on
+	// naming as long as it's valid.
+	return prefix + "_" + name
+}
+
 type Unmarshaler interface {
 	// Unmarshal expects a struct pointer and will read in
 	// the config values into the underlying struct.
@@ -0,0 +1,67 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keyReplacer converts a dotted or dashed key to an env name
+var keyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// KeyName converts a key to an env name by uppercasing it and
+// replacing dots and dashes with underscores (ie db.host -> DB_HOST).
+func KeyName(key string) string {
+	return strings.ToUpper(keyReplacer.Replace(key))
+}
+
+// ConfigMapFiles returns the files of a mounted Kubernetes ConfigMap or Secret
+// volume keyed by env name. Each file name is a key that is converted
+// to an env name with KeyName.
+//
+// Kubernetes mounts each key as a symlink into the ..data directory which is
+// swapped atomically on update. Hidden entries (ie ..data and the timestamped
+// directories) are skipped and symlinks are followed.
+func ConfigMapFiles(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		info, err := os.Stat(path) // follow the ..data symlink
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		files[KeyName(e.Name())] = path
+	}
+	return files, nil
+}
+
+// LoadConfigMap reads each file in a mounted ConfigMap or Secret volume dir (see ConfigMapFiles)
+// and unmarshals the values into v via Decoder. The trailing newline of each file is removed.
+func LoadConfigMap(dir string, v interface{}) error {
+	files, err := ConfigMapFiles(dir)
+	if err != nil {
+		return err
+	}
+	m := make(map[string]string, len(files))
+	for name, path := range files {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		m[name] = strings.TrimRight(string(b), "\r\n")
+	}
+	d := &Decoder{
+		GetVal: func(k string) string { return m[k] },
+	}
+	return d.Unmarshal(v)
+}
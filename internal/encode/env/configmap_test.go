@@ -0,0 +1,50 @@
+package env
+
+import (
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestConfigMapFiles(t *testing.T) {
+	files, err := ConfigMapFiles("../../../test/configmap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]string{
+		"HOST":    "../../../test/configmap/host",
+		"DB_UN":   "../../../test/configmap/db.un",
+		"DB_PORT": "../../../test/configmap/DB_PORT",
+	}
+	if eq, diff := trial.Equal(files, exp); !eq {
+		t.Error(diff)
+	}
+}
+
+func TestLoadConfigMap(t *testing.T) {
+	type db struct {
+		Username string `env:"UN"`
+		Port     int
+	}
+	type config struct {
+		Host string
+		DB   db
+		Name string
+	}
+	fn := func(dir string) (config, error) {
+		c := config{Name: "default"}
+		err := LoadConfigMap(dir, &c)
+		return c, err
+	}
+	cases := trial.Cases[string, config]{
+		"symlinked keys": {
+			Input:    "../../../test/configmap",
+			Expected: config{Host: "cmhost", DB: db{Username: "cmuser", Port: 9}, Name: "default"},
+		},
+		"missing dir": {
+			Input:     "../../../test/missing",
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
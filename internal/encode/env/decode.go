@@ -4,8 +4,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-
-	"github.com/iancoleman/strcase"
+	"strings"
 
 	"github.com/hydronica/go-config/internal/encode"
 )
@@ -18,8 +17,22 @@ func New() *Decoder {
 
 type Decoder struct {
 	GetVal func(key string) string
+
+	// FileVars reads the value from the file at <NAME>_FILE when <NAME> is
+	// not set (ie DB_PASSWORD_FILE=/run/secrets/db_password). The trailing
+	// newline of the file is removed.
+	FileVars bool
+
+	// Prefix is prepended to every env name (ie MYAPP_HOST)
+	Prefix string
+
+	// Fallback to the unprefixed env name when the prefixed name is not set.
+	Fallback bool
 }
 
+// FileSuffix is appended to an env name for the path of a file holding its value.
+const FileSuffix = "_FILE"
+
 // Unmarshal implements the go-config/encoding.Unmarshaler interface.
 func (d *Decoder) Unmarshal(v interface{}) error {
 	if d.GetVal == nil {
@@ -34,67 +47,58 @@ func (d *Decoder) Unmarshal(v interface{}) error {
 		return fmt.Errorf("'%v' must be a non-nil pointer struct", reflect.TypeOf(v))
 	}
 
-	// iterate through struct fields.
-	vStruct := reflect.ValueOf(v).Elem()
-	for i := 0; i < vStruct.NumField(); i++ {
-		field := vStruct.Field(i)
-
-		if !field.CanSet() { // skip private variables
+	for _, f := range encode.Fields(v, ".") {
+		// Validate "omitprefix" usage. Cannot be used on non-struct
+		// field types as only those are returned by Fields.
+		if f.Struct.Tag.Get(encode.EnvTag) == "omitprefix" {
+			return fmt.Errorf("'omitprefix' cannot be used on non-struct field types")
+		}
+		if f.Env == "" {
 			continue
 		}
+		envVal, err := d.value(f.Env, f.EnvAliases)
+		if err != nil {
+			return err
+		}
 
-		// Check general 'config' tag value. if it has a "ignore" value
-		// then skip it entirely.
-		if cfgV := vStruct.Type().Field(i).Tag.Get(encode.ConfigTag); cfgV == "ignore" {
+		// if no value found then don't set because it will
+		// overwrite possible defaults.
+		if envVal == "" {
 			continue
 		}
-
-		// env tag name, if present, trumps the generated field name.
-		//
-		// If the field name is used it is converted to screaming snake case (uppercase with underscores).
-		name := vStruct.Type().Field(i).Name
-		tag := vStruct.Type().Field(i).Tag.Get(encode.EnvTag) // env tag value
-		switch tag {
-		case "-":
-			continue // ignore field
-		case "omitprefix":
-			// Should only be used on struct field types, in
-			// which case an existing prefix is passed through
-			// to the struct fields. The immediate struct field
-			// has no prefix.
-			name = ""
-		case "":
-			name = strcase.ToScreamingSnake(name)
-		default:
-			name = tag
+		if err := encode.SetField(f.Alloc(), envVal, f.Struct); err != nil {
+			return fmt.Errorf("'%s' from '%s' cannot be set to %s (%s) %v", envVal, f.Env, f.Struct.Name, f.Value.Type(), err)
 		}
+	}
+	return nil
+}
 
-		// if the value type is a struct or struct pointer then recurse.
-		switch field.Kind() {
-		// explicity ignored list of types.
-		case reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.Interface, reflect.Map:
+// value of the first env variable of the field that is set or the contents of
+// the file at <NAME>_FILE when enabled and <NAME> is not set (see LookupNames).
+func (d *Decoder) value(name string, aliases []string) (string, error) {
+	for _, n := range d.LookupNames(name, aliases) {
+		if v := d.GetVal(n); v != "" {
+			return v, nil
+		}
+		path := d.GetVal(n + FileSuffix)
+		if !d.FileVars || path == "" {
 			continue
-		default:
-			// Validate "omitprefix" usage.
-			// Cannot be used on non-struct field types.
-			if tag == "omitprefix" {
-				return fmt.Errorf("'omitprefix' cannot be used on non-struct field types")
-			}
-
-			// get env value
-			envVal := d.GetVal(name)
-
-			// if no value found then don't set because it will
-			// overwrite possible defaults.
-			if envVal == "" {
-				continue
-			}
-			// set value to field.
-			if err := encode.SetField(field, envVal, vStruct.Type().Field(i)); err != nil {
-				return fmt.Errorf("'%s' from '%s' cannot be set to %s (%s) %v", envVal, name, vStruct.Type().Field(i).Name, field.Type(), err)
-			}
 		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("'%s%s' %w", n, FileSuffix, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
 	}
+	return "", nil
+}
 
-	return nil
+// LookupNames returns the env names to look up in order (see encode.EnvNames).
+// The unprefixed name follows the prefixed name when Fallback is enabled.
+func (d *Decoder) LookupNames(name string, aliases []string) []string {
+	names := encode.EnvNames(d.Prefix, name, aliases)
+	if d.Prefix == "" || !d.Fallback {
+		return names
+	}
+	return append([]string{names[0], name}, names[1:]...)
 }
@@ -57,6 +57,12 @@ func TestDecoder_Unmarshal(t *testing.T) {
 		MStruct mStruct  `env:"MSTRUCT"`
 		PStruct *mStruct `env:"PSTRUCT"`
 	}
+	type nStruct struct {
+		Host   string
+		DB     dbConfig `env:"DB"`
+		Backup *dbConfig
+		Shared dbConfig `env:"omitprefix"`
+	}
 	type input struct {
 		config interface{}
 		args   map[string]string
@@ -189,6 +195,32 @@ func TestDecoder_Unmarshal(t *testing.T) {
 			},
 			Expected: &tStruct{MStruct: mStruct{"abc"}, PStruct: &mStruct{"def"}},
 		},
+		"nested struct": {
+			Input: input{
+				config: &nStruct{DB: dbConfig{Username: "default"}},
+				args:   map[string]string{"HOST": "localhost", "DB_PW": "secret", "UN": "shared"},
+			},
+			Expected: &nStruct{
+				Host:   "localhost",
+				DB:     dbConfig{Username: "default", Password: "secret"},
+				Shared: dbConfig{Username: "shared"},
+			},
+		},
+		"nil struct pointer set": {
+			Input: input{
+				config: &nStruct{},
+				args:   map[string]string{"BACKUP_UN": "admin"},
+			},
+			Expected: &nStruct{Backup: &dbConfig{Username: "admin"}},
+		},
+		"omitprefix on non-struct": {
+			Input: input{
+				config: &struct {
+					Name string `env:"omitprefix"`
+				}{},
+			},
+			ShouldErr: true,
+		},
 		"keep value for default": {
 			Input: input{
 				config: &tConfig{
@@ -209,6 +241,106 @@ func TestDecoder_Unmarshal(t *testing.T) {
 	trial.New(fn, cases).SubTest(t)
 }
 
+func TestDecoder_FileVars(t *testing.T) {
+	fn := func(args map[string]string) (dbConfig, error) {
+		os.Clearenv()
+		for key, value := range args {
+			os.Setenv(key, value)
+		}
+		c := dbConfig{}
+		d := New()
+		d.FileVars = true
+		err := d.Unmarshal(&c)
+		return c, err
+	}
+	cases := trial.Cases[map[string]string, dbConfig]{
+		"read file": {
+			Input:    map[string]string{"PW_FILE": "../../../test/secrets/db_pw"},
+			Expected: dbConfig{Password: "filepw"},
+		},
+		"env takes precedence": {
+			Input:    map[string]string{"PW": "env", "PW_FILE": "../../../test/secrets/db_pw"},
+			Expected: dbConfig{Password: "env"},
+		},
+		"missing file": {
+			Input:     map[string]string{"UN_FILE": "../../../test/secrets/missing"},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestDecoder_Prefix(t *testing.T) {
+	type input struct {
+		fallback bool
+		args     map[string]string
+	}
+	fn := func(in input) (dbConfig, error) {
+		os.Clearenv()
+		for key, value := range in.args {
+			os.Setenv(key, value)
+		}
+		c := dbConfig{}
+		d := New()
+		d.Prefix, d.Fallback = "MYAPP", in.fallback
+		err := d.Unmarshal(&c)
+		return c, err
+	}
+	cases := trial.Cases[input, dbConfig]{
+		"prefixed": {
+			Input:    input{args: map[string]string{"MYAPP_UN": "admin", "PW": "ignored"}},
+			Expected: dbConfig{Username: "admin"},
+		},
+		"fallback": {
+			Input:    input{fallback: true, args: map[string]string{"MYAPP_UN": "admin", "UN": "other", "PW": "secret"}},
+			Expected: dbConfig{Username: "admin", Password: "secret"},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestDecoder_Aliases(t *testing.T) {
+	type aliasConfig struct {
+		Port int `env:"HTTP_PORT|PORT|SERVER_PORT"`
+		DB   struct {
+			Username string `env:"UN|USER"`
+		}
+	}
+	fn := func(args map[string]string) (aliasConfig, error) {
+		os.Clearenv()
+		for key, value := range args {
+			os.Setenv(key, value)
+		}
+		c := aliasConfig{}
+		err := New().Unmarshal(&c)
+		return c, err
+	}
+	cases := trial.Cases[map[string]string, aliasConfig]{
+		"primary wins": {
+			Input:    map[string]string{"HTTP_PORT": "80", "PORT": "8080"},
+			Expected: aliasConfig{Port: 80},
+		},
+		"first alias present": {
+			Input:    map[string]string{"SERVER_PORT": "9090", "PORT": "8080"},
+			Expected: aliasConfig{Port: 8080},
+		},
+		"nested alias": {
+			Input: map[string]string{"USER": "admin", "DB_USER": "nested"},
+			Expected: func() aliasConfig {
+				c := aliasConfig{}
+				c.DB.Username = "admin"
+				return c
+			}(),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+type dbConfig struct {
+	Username string `env:"UN"`
+	Password string `env:"PW"`
+}
+
 type mStruct struct {
 	value string
 }
@@ -8,28 +8,43 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/hydronica/go-config/internal/encode"
 )
 
 // LoadEnvFile opens path, parses dotenv lines into a map, and unmarshals into v via Decoder.
 // Callers may use readDotenvMap + Decoder directly for other flows.
 func LoadEnvFile(path string, v interface{}) error {
-	f, err := os.Open(path)
+	return (&Decoder{}).LoadFile(path, v)
+}
+
+// LoadFile is the same as LoadEnvFile except the names are looked up
+// with the Decoder's settings (ie Prefix) instead of GetVal.
+func (d Decoder) LoadFile(path string, v interface{}) error {
+	m, err := ReadEnvFile(path)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	m, err := readDotenvMap(f)
+	d.GetVal = func(k string) string { return m[k] }
+	return d.Unmarshal(v)
+}
+
+// ReadEnvFile returns the variables of the .env file at path (see readDotenvMap).
+func ReadEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return err
-	}
-	d := &Decoder{
-		GetVal: func(k string) string { return m[k] },
+		return nil, err
 	}
-	return d.Unmarshal(v)
+	defer f.Close()
+	return readDotenvMap(f)
 }
 
 // readDotenvMap reads r line by line. Each non-empty, non-comment line is split on the first
 // '=' into key and value (trimmed). Malformed quoted values return an error and a nil map.
+//
+// Unquoted and double-quoted values have ${VAR} references expanded (see encode.ExpandStrict)
+// using keys defined earlier in r and then the process env, the same as config files. A reference
+// to a variable that is not set is an error. Single-quoted values are literal.
 func readDotenvMap(r io.Reader) (map[string]string, error) {
 	sc := bufio.NewScanner(r)
 	vars := make(map[string]string)
@@ -48,10 +63,21 @@ func readDotenvMap(r io.Reader) (map[string]string, error) {
 		if key == "" {
 			continue
 		}
-		val, err := parseDotenvLineValue(line[eq+1:])
+		val, literal, err := parseDotenvLineValue(line[eq+1:])
 		if err != nil {
 			return nil, fmt.Errorf("dotenv: line %d: %w", lineNo, err)
 		}
+		if !literal {
+			val, err = encode.ExpandStrict(val, func(k string) (string, bool) {
+				if v, ok := vars[k]; ok {
+					return v, true
+				}
+				return os.LookupEnv(k)
+			})
+			if err != nil {
+				return nil, fmt.Errorf("dotenv: line %d: %w", lineNo, err)
+			}
+		}
 		vars[key] = val
 	}
 	if err := sc.Err(); err != nil {
@@ -60,29 +86,30 @@ func readDotenvMap(r io.Reader) (map[string]string, error) {
 	return vars, nil
 }
 
-func parseDotenvLineValue(raw string) (string, error) {
+// parseDotenvLineValue returns the unquoted value and if it was single-quoted (literal).
+func parseDotenvLineValue(raw string) (val string, literal bool, err error) {
 	raw = strings.TrimSpace(raw)
 	if len(raw) == 0 {
-		return "", nil
+		return "", false, nil
 	}
 
 	//what does our quote look like?
 	q := raw[0]
 	if q != '"' && q != '\'' {
-		return trimUnquotedInlineComment(raw), nil
+		return trimUnquotedInlineComment(raw), false, nil
 	}
 
 	closeIdx, err := findClosingQuote(raw, q)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	if err := validateQuotedValueSuffix(raw, closeIdx+1); err != nil {
-		return "", err
+		return "", false, err
 	}
 	if q == '\'' { //literal
-		return unescape1Quoted(raw[1:closeIdx]), nil
+		return unescape1Quoted(raw[1:closeIdx]), true, nil
 	}
-	return unescape2Quoted(raw[1:closeIdx]), nil
+	return unescape2Quoted(raw[1:closeIdx]), false, nil
 
 }
 
@@ -144,6 +171,8 @@ func unescape2Quoted(s string) string {
 				b.WriteByte('\t')
 			case '"', '\\':
 				b.WriteByte(s[i+1])
+			case '$': // kept escaped so it is not expanded
+				b.WriteString("$$")
 			default:
 				b.WriteByte(s[i+1])
 			}
@@ -180,14 +209,20 @@ func needsEnvQuotes(s string) bool {
 		return true
 	}
 	for _, r := range s {
-		if unicode.IsSpace(r) || r == '#' || r == '=' || r == '"' || r == '\'' || r == '\\' {
+		if unicode.IsSpace(r) || r == '#' || r == '=' || r == '"' || r == '\'' || r == '\\' || r == '$' || r == '`' {
 			return true
 		}
 	}
 	return false
 }
 
+// quoteEnvString quotes s so it reads back the same from a .env file and from a shell.
+// A value with a '$' is single-quoted so neither expands it. Values that can't be
+// single-quoted are double-quoted with '$' and '`' escaped.
 func quoteEnvString(s string) string {
+	if strings.Contains(s, "$") && !strings.ContainsAny(s, "'\\\n\r") {
+		return "'" + s + "'"
+	}
 	var b strings.Builder
 	b.WriteByte('"')
 	for i := 0; i < len(s); i++ {
@@ -202,6 +237,9 @@ func quoteEnvString(s string) string {
 			b.WriteString(`\r`)
 		case '\t':
 			b.WriteString(`\t`)
+		case '$', '`':
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
 		default:
 			b.WriteByte(s[i])
 		}
@@ -135,6 +135,40 @@ X=second`,
 			Input:    `EMPTY=""`,
 			Expected: map[string]string{"EMPTY": ""},
 		},
+		"expand_earlier_key": {
+			Input: `HOST=localhost
+URL=http://${HOST}:${GO_CONFIG_TEST_PORT:-80}
+QUOTED="${HOST} db"`,
+			Expected: map[string]string{
+				"HOST":   "localhost",
+				"URL":    "http://localhost:80",
+				"QUOTED": "localhost db",
+			},
+		},
+		"single_quoted_not_expanded": {
+			Input:    `LIT='${HOST}'`,
+			Expected: map[string]string{"LIT": "${HOST}"},
+		},
+		"escaped_dollar": {
+			Input:    `PRICE="$$5"`,
+			Expected: map[string]string{"PRICE": "$5"},
+		},
+		"backslash_dollar": {
+			Input:    `PRICE="\$5 \${HOST}"`,
+			Expected: map[string]string{"PRICE": "$5 ${HOST}"},
+		},
+		"expand_unset": {
+			Input:       `X=a${GO_CONFIG_MISSING}b`,
+			ExpectedErr: errors.New("${GO_CONFIG_MISSING} is not set"),
+		},
+		"expand_unset_default": {
+			Input:    `X=a${GO_CONFIG_MISSING:-}b`,
+			Expected: map[string]string{"X": "ab"},
+		},
+		"expand_required_missing": {
+			Input:       `X=${GO_CONFIG_MISSING:?must be set}`,
+			ExpectedErr: errors.New("GO_CONFIG_MISSING: must be set"),
+		},
 		"unterminated_double_quote": {
 			Input: `OK=1
 BAD="no closing quote
@@ -2,13 +2,13 @@ package env
 
 import (
 	"bytes"
+	"encoding"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/iancoleman/strcase"
-
 	"github.com/hydronica/go-config/internal/encode"
 )
 
@@ -20,13 +20,16 @@ func NewEncoder() *Encoder {
 
 type Encoder struct {
 	buf *bytes.Buffer
-}
 
-func (e *Encoder) Marshal(v interface{}) ([]byte, error) {
-	return e.marshal("", v)
+	// FileVars lists the <NAME>_FILE alternative (see Decoder.FileVars)
+	// as a comment after each variable.
+	FileVars bool
+
+	// Prefix is prepended to every env name (ie MYAPP_HOST)
+	Prefix string
 }
 
-func (e *Encoder) marshal(prefix string, v interface{}) ([]byte, error) {
+func (e *Encoder) Marshal(v interface{}) ([]byte, error) {
 	// Verify that v is struct pointer. Should not be nil.
 	if value := reflect.ValueOf(v); value.Kind() != reflect.Ptr || value.IsNil() {
 		return nil, fmt.Errorf("'%v' must be a non-nil pointer", reflect.TypeOf(v))
@@ -36,53 +39,18 @@ func (e *Encoder) marshal(prefix string, v interface{}) ([]byte, error) {
 		return nil, fmt.Errorf("'%v' must be a non-nil pointer struct", reflect.TypeOf(v))
 	}
 
-	// iterate through the struct field.
-	vStruct := reflect.ValueOf(v).Elem()
-	for i := 0; i < vStruct.NumField(); i++ {
-		field := vStruct.Field(i)
-
-		if !field.CanSet() { // skip private variables
-			continue
+	// nil struct pointers are walked as an empty struct so
+	// that all fields are represented in the template.
+	for _, f := range encode.Fields(v, ".") {
+		// Validate "omitprefix" usage.
+		// Cannot be used on non-struct field types.
+		if f.Struct.Tag.Get(encode.EnvTag) == "omitprefix" {
+			return nil, fmt.Errorf("'omitprefix' cannot be used on non-struct field types")
 		}
-
-		// Check general 'config' tag value. if it has a "ignore" value
-		// then skip it entirely.
-		if cfgV := vStruct.Type().Field(i).Tag.Get(encode.ConfigTag); cfgV == "ignore" {
+		if f.Env == "" {
 			continue
 		}
-
-		// env tag name, if present, trumps the generated field name.
-		//
-		// If the field name is used it is converted to screaming snake case (uppercase with underscores).
-		name := vStruct.Type().Field(i).Name
-		tag := vStruct.Type().Field(i).Tag.Get(encode.EnvTag) // env tag value
-		switch tag {
-		case "-":
-			continue // ignore field
-		case "omitprefix":
-			// Should only be used on struct field types, in
-			// which case an existing prefix is passed through
-			// to the struct fields. The immediate struct field
-			// has no prefix.
-			name = ""
-		case "":
-			name = strcase.ToScreamingSnake(name)
-		default:
-			name = tag
-		}
-
-		// prepend prefix
-		if prefix != "" {
-			// An empty name takes on the prefix so that
-			// it can passthrough if the type is a struct or pointer struct.
-			if name == "" {
-				name = prefix
-			} else {
-				// An existing underscore means there will be 2 underscores. The user is given almost full reign on
-				// naming as long as it's valid.
-				name = prefix + "_" + name
-			}
-		}
+		name, aliases, field, sField := f.Env, f.EnvAliases, f.Value, f.Struct
 	typeCheck:
 		// if the value type is a struct or struct pointer then recurse.
 		switch field.Kind() {
@@ -90,32 +58,41 @@ func (e *Encoder) marshal(prefix string, v interface{}) ([]byte, error) {
 		case reflect.Array, reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.Interface, reflect.Map:
 			continue
 		case reflect.String:
-			e.write(name, field.String())
+			e.write(name, aliases, field.String(), sField)
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			if field.Type().String() == "time.Duration" {
-				e.write(name, field.Interface().(time.Duration).String())
+				e.write(name, aliases, field.Interface().(time.Duration).String(), sField)
 				continue
 			}
-			e.write(name, field.Int())
+			e.write(name, aliases, field.Int(), sField)
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			e.write(name, field.Uint())
+			e.write(name, aliases, field.Uint(), sField)
 		case reflect.Bool:
-			e.write(name, field.Bool())
+			e.write(name, aliases, field.Bool(), sField)
 		case reflect.Float32, reflect.Float64:
-			e.write(name, field.Float())
+			e.write(name, aliases, field.Float(), sField)
 
 		case reflect.Struct:
 			// time.Time special struct case
 			if field.Type().String() == "time.Time" {
 				// check for 'fmt' tag.
-				timeFmt := vStruct.Type().Field(i).Tag.Get(encode.FormatTag)
+				timeFmt := sField.Tag.Get(encode.FormatTag)
 				if timeFmt == "" {
 					timeFmt = time.RFC3339
 				}
-				e.write(name, field.Interface().(time.Time).Format(timeFmt))
+				e.write(name, aliases, field.Interface().(time.Time).Format(timeFmt), sField)
 				continue
 			}
 
+			// support a struct if it implements a marshaler
+			if m, ok := field.Interface().(encoding.TextMarshaler); ok {
+				b, err := m.MarshalText()
+				if err != nil {
+					return nil, err
+				}
+				e.write(name, aliases, string(b), sField)
+			}
+
 		case reflect.Ptr:
 			// if it's a ptr to a struct then recurse otherwise fallthrough
 			if field.IsNil() {
@@ -132,8 +109,23 @@ func (e *Encoder) marshal(prefix string, v interface{}) ([]byte, error) {
 	return e.buf.Bytes(), nil
 }
 
-func (e *Encoder) write(field string, value interface{}) {
-	fmt.Fprintf(e.buf, "%s=%s\n", field, stringifyForEnv(value))
+// write the env line for the field. Required fields are
+// marked with a trailing comment and alternative names are
+// listed as comments on the following lines.
+func (e *Encoder) write(field string, aliases []string, value interface{}, sField reflect.StructField) {
+	names := encode.EnvNames(e.Prefix, field, aliases)
+	field = names[0]
+	if sField.Tag.Get(encode.ReqTag) == "true" {
+		fmt.Fprintf(e.buf, "%s=%s # required\n", field, stringifyForEnv(value))
+	} else {
+		fmt.Fprintf(e.buf, "%s=%s\n", field, stringifyForEnv(value))
+	}
+	if e.FileVars {
+		fmt.Fprintf(e.buf, "# %s%s=\n", field, FileSuffix)
+	}
+	if len(names) > 1 {
+		fmt.Fprintf(e.buf, "# aliases: %s\n", strings.Join(names[1:], ", "))
+	}
 }
 
 func stringifyForEnv(v interface{}) string {
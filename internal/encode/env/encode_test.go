@@ -77,6 +77,14 @@ func TestEncoder_Marshal(t *testing.T) {
 			Input:    &struct{ String string }{String: "p#q"},
 			Expected: "STRING=\"p#q\"\n",
 		},
+		"string with dollar": {
+			Input:    &struct{ String string }{String: "${X}"},
+			Expected: "STRING='${X}'\n",
+		},
+		"string with dollar and quote": {
+			Input:    &struct{ String string }{String: "it's $5 `cmd`"},
+			Expected: "STRING=\"it's \\$5 \\`cmd\\`\"\n",
+		},
 		"with tags": {
 			Input: &struct {
 				Int  int    `env:"COUNT" comment:"number of people in a room"`
@@ -114,6 +122,65 @@ func TestEncoder_Marshal(t *testing.T) {
 			},
 			Expected: "INT=1\nUINT=2\nFLOAT=3.4\n",
 		},
+		"required": {
+			Input: &struct {
+				Host string `req:"true"`
+				Port int
+			}{Port: 8080},
+			Expected: "HOST=\"\" # required\nPORT=8080\n",
+		},
+		"aliases": {
+			Input: &struct {
+				Port int `env:"HTTP_PORT|PORT|SERVER_PORT"`
+			}{Port: 8080},
+			Expected: "HTTP_PORT=8080\n# aliases: PORT, SERVER_PORT\n",
+		},
+		"nested": {
+			Input: &struct {
+				Host   string
+				DB     dbConfig `env:"DB"`
+				Backup *dbConfig
+				Shared dbConfig `env:"omitprefix"`
+			}{
+				Host: "localhost",
+				DB:   dbConfig{Username: "admin"},
+			},
+			Expected: "HOST=localhost\nDB_UN=admin\nDB_PW=\"\"\nBACKUP_UN=\"\"\nBACKUP_PW=\"\"\nUN=\"\"\nPW=\"\"\n",
+		},
 	}
 	trial.New(fn, cases).Test(t)
 }
+
+func TestEncoder_FileVars(t *testing.T) {
+	e := NewEncoder()
+	e.FileVars = true
+	b, err := e.Marshal(&struct {
+		Host string `req:"true"`
+		DB   dbConfig
+	}{DB: dbConfig{Username: "admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "HOST=\"\" # required\n# HOST_FILE=\n" +
+		"DB_UN=admin\n# DB_UN_FILE=\n" +
+		"DB_PW=\"\"\n# DB_PW_FILE=\n"
+	if eq, diff := trial.Equal(string(b), exp); !eq {
+		t.Error(diff)
+	}
+}
+
+func TestEncoder_Prefix(t *testing.T) {
+	e := NewEncoder()
+	e.Prefix = "MYAPP"
+	b, err := e.Marshal(&struct {
+		Host string   `env:"HOST|HOSTNAME"`
+		DB   dbConfig `env:"DB"`
+	}{Host: "localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "MYAPP_HOST=localhost\n# aliases: HOSTNAME\nMYAPP_DB_UN=\"\"\nMYAPP_DB_PW=\"\"\n"
+	if eq, diff := trial.Equal(string(b), exp); !eq {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,284 @@
+package encode
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+)
+
+// Field describes a single value within a config struct along with
+// the names used to set it from each source.
+type Field struct {
+	Path string // struct field path (ie DB.Username)
+	Flag string // flag name without the dash, empty if the field has no flag
+	Env  string // env variable name, empty if the field has no env variable
+	Key  string // dotted file key (ie db.username)
+
+	EnvAliases []string // alternative env variable names used as is (ie `env:"HTTP_PORT|PORT"`), see EnvNames
+	Sources    []string // sources allowed to set the field, nil for all (see Sources)
+
+	Value  reflect.Value
+	Struct reflect.StructField
+
+	// Parents are the nested struct fields the field is in, outermost first.
+	Parents []reflect.StructField
+
+	// Nil is set when the field is in a nil struct pointer. The Value is a
+	// zero value that is not part of the struct, use Alloc to set the field.
+	Nil bool
+
+	root  reflect.Value // struct the field was walked from
+	index []int         // field index within each nested struct
+}
+
+// Alloc returns the field's value within the struct allocating the nil
+// struct pointers it is in. Decoders should only call Alloc when the field
+// is set so that a nil struct pointer stays nil unless one of its fields is set.
+func (f Field) Alloc() reflect.Value {
+	v := f.root
+	for _, i := range f.index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+// FormatKey returns the dotted key of the field for the struct tag of a file
+// format (ie json or yaml), see FormatKey.
+func (f Field) FormatKey(tag string) string {
+	return FormatKey(append(f.Parents[:len(f.Parents):len(f.Parents)], f.Struct), tag)
+}
+
+// FormatKey returns the dotted key of the nested struct fields for the struct
+// tag of a file format following the rules of its decoder. The name is the tag
+// name or the field name (lowercase except for json) and embedded structs
+// without a tag name (yaml with the inline flag) have their fields promoted.
+// Empty if one of the fields is excluded from the format with "-".
+func FormatKey(fields []reflect.StructField, tag string) string {
+	key := ""
+	for _, sField := range fields {
+		opts := strings.Split(sField.Tag.Get(tag), ",")
+		name := opts[0]
+		switch {
+		case name == "-":
+			return ""
+		case tag == "yaml" && contains(opts[1:], "inline"):
+			continue
+		case tag != "yaml" && name == "" && sField.Anonymous:
+			continue
+		case name == "" && tag == "json":
+			name = sField.Name
+		case name == "":
+			name = strings.ToLower(sField.Name)
+		}
+		key = join(key, name, ".")
+	}
+	return key
+}
+
+// Fields walks the struct pointer v and returns every configurable value.
+// Nested structs and struct pointers are walked rather than returned and
+// their names are used as a prefix for their child fields following the same
+// rules as the flag (joined with flagSep) and env (joined with "_") encoders.
+//
+// Nested struct types already on the path are skipped (see TypePath).
+//
+// A nil struct pointer is walked as an empty struct so its fields are still
+// described, but their Values are not part of the struct (see Field.Alloc).
+func Fields(v interface{}, flagSep string) []Field {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return fields(prefix{types: TypePath{value.Elem().Type()}, root: value.Elem()}, value.Elem(), flagSep)
+}
+
+// prefix of a nested struct for each naming scheme. A source is
+// disabled for all child fields when the parent struct is excluded
+// from that source (ie `flag:"-"`).
+type prefix struct {
+	path, flag, env, key string
+	envAliases           []string
+	sources              []string
+	noFlag, noEnv, noKey bool
+	types                TypePath
+
+	parents []reflect.StructField
+	nil     bool
+	root    reflect.Value
+	index   []int
+}
+
+func fields(p prefix, vStruct reflect.Value, flagSep string) []Field {
+	var list []Field
+	for i := 0; i < vStruct.NumField(); i++ {
+		field := vStruct.Field(i)
+		sField := vStruct.Type().Field(i)
+		if !field.CanSet() || sField.Tag.Get(ConfigTag) == "ignore" {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.Interface:
+			continue
+		}
+		child := prefix{
+			path:    join(p.path, sField.Name, "."),
+			sources: Sources(sField, p.sources),
+			parents: p.parents,
+			nil:     p.nil,
+			root:    p.root,
+			index:   append(p.index[:len(p.index):len(p.index)], i),
+		}
+
+		// flag name, embedded structs without a tag have their fields promoted
+		flagTag := sField.Tag.Get(FlagTag)
+		switch {
+		case p.noFlag || flagTag == "-":
+			child.noFlag = true
+		case flagTag == "" && sField.Anonymous:
+			child.flag = p.flag
+		case flagTag == "":
+			child.flag = join(p.flag, strcase.ToKebab(sField.Name), flagSep)
+		default:
+			child.flag = join(p.flag, flagTag, flagSep)
+		}
+
+		// env name
+		envTag := sField.Tag.Get(EnvTag)
+		switch {
+		case p.noEnv || envTag == "-":
+			child.noEnv = true
+		case envTag == "omitprefix":
+			child.env = p.env
+		case envTag == "":
+			child.env = join(p.env, strcase.ToScreamingSnake(sField.Name), "_")
+		default:
+			names := strings.Split(envTag, EnvAliasSep)
+			child.env = join(p.env, names[0], "_")
+			child.envAliases = names[1:]
+		}
+
+		// file key, embedded structs without a tag have their fields promoted
+		keyTag := strings.Split(sField.Tag.Get("toml"), ",")[0]
+		switch {
+		case p.noKey || keyTag == "-":
+			child.noKey = true
+		case keyTag == "" && sField.Anonymous:
+			child.key = p.key
+		case keyTag == "":
+			child.key = join(p.key, strings.ToLower(sField.Name), ".")
+		default:
+			child.key = join(p.key, keyTag, ".")
+		}
+
+		if IsNested(field.Type()) {
+			if p.types.Contains(field.Type()) {
+				continue
+			}
+			child.types = p.types.Add(field.Type())
+			child.parents = append(p.parents[:len(p.parents):len(p.parents)], sField)
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					field = reflect.New(field.Type().Elem())
+					child.nil = true
+				}
+				field = field.Elem()
+			}
+			list = append(list, fields(child, field, flagSep)...)
+			continue
+		}
+
+		f := Field{
+			Path:   child.path,
+			Flag:   child.flag,
+			Env:    child.env,
+			Key:    child.key,
+			Value:  field,
+			Struct: sField,
+
+			EnvAliases: child.envAliases,
+			Sources:    child.sources,
+			Parents:    child.parents,
+			Nil:        child.nil,
+
+			root:  child.root,
+			index: child.index,
+		}
+		switch field.Kind() {
+		case reflect.Map:
+			f.Flag, f.Env, f.EnvAliases = "", "", nil
+		case reflect.Slice, reflect.Array:
+			f.Flag = ""
+		}
+		list = append(list, f)
+	}
+	return list
+}
+
+// IsNested checks if t is a struct (or struct pointer) whose fields are
+// configured individually rather than as a single value. time.Time and
+// types implementing encoding.TextMarshaler or encoding.TextUnmarshaler
+// are treated as single values.
+func IsNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.String() == "time.Time" {
+		return false
+	}
+	return !t.Implements(reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()) &&
+		!reflect.PtrTo(t).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+// TypePath is the struct types of the nested structs being walked.
+// A self-referential type (ie type Node struct { Next *Node }) would be
+// walked forever, so a type already on the path is skipped.
+type TypePath []reflect.Type
+
+// Contains reports if the struct or struct pointer type t is on the path.
+func (p TypePath) Contains(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for _, v := range p {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Add returns a copy of the path with the struct or struct pointer type t appended.
+func (p TypePath) Add(t reflect.Type) TypePath {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return append(p[:len(p):len(p)], t)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// join name to the prefix with sep. An empty name takes on the prefix.
+func join(prefix, name, sep string) string {
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	}
+	return prefix + sep + name
+}
@@ -0,0 +1,143 @@
+package encode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hydronica/trial"
+)
+
+func TestFields(t *testing.T) {
+	type db struct {
+		Username string `flag:"un" env:"UN"`
+		Password string `flag:"pw" env:"PW|PASSWORD" toml:"pass"`
+	}
+	type Embed struct {
+		Level int
+	}
+	type config struct {
+		Host    string
+		Time    time.Time
+		DB      db  `flag:"db"`
+		Backup  *db `env:"omitprefix"`
+		NoFlag  db  `flag:"-"`
+		Tags    []string
+		Map     map[string]string
+		Ignored string `config:"ignore"`
+		private string
+		Embed
+	}
+	type node struct {
+		Name  string
+		Next  *node
+		Child struct {
+			Prev *node
+			Size int
+		}
+	}
+	type output struct {
+		Path, Flag, Env, Key string
+		EnvAliases           []string
+	}
+	fn := func(in interface{}) ([]output, error) {
+		var out []output
+		for _, f := range Fields(in, ".") {
+			out = append(out, output{Path: f.Path, Flag: f.Flag, Env: f.Env, Key: f.Key, EnvAliases: f.EnvAliases})
+		}
+		return out, nil
+	}
+	cases := trial.Cases[interface{}, []output]{
+		"nil": {
+			Input:    nil,
+			Expected: nil,
+		},
+		"non pointer": {
+			Input:    config{},
+			Expected: nil,
+		},
+		"nested": {
+			Input: &config{},
+			Expected: []output{
+				{Path: "Host", Flag: "host", Env: "HOST", Key: "host"},
+				{Path: "Time", Flag: "time", Env: "TIME", Key: "time"},
+				{Path: "DB.Username", Flag: "db.un", Env: "DB_UN", Key: "db.username"},
+				{Path: "DB.Password", Flag: "db.pw", Env: "DB_PW", Key: "db.pass", EnvAliases: []string{"PASSWORD"}},
+				{Path: "Backup.Username", Flag: "backup.un", Env: "UN", Key: "backup.username"},
+				{Path: "Backup.Password", Flag: "backup.pw", Env: "PW", Key: "backup.pass", EnvAliases: []string{"PASSWORD"}},
+				{Path: "NoFlag.Username", Env: "NO_FLAG_UN", Key: "noflag.username"},
+				{Path: "NoFlag.Password", Env: "NO_FLAG_PW", Key: "noflag.pass", EnvAliases: []string{"PASSWORD"}},
+				{Path: "Tags", Env: "TAGS", Key: "tags"},
+				{Path: "Map", Key: "map"},
+				{Path: "Embed.Level", Flag: "level", Env: "EMBED_LEVEL", Key: "level"},
+			},
+		},
+		"self-referential": {
+			Input: &node{},
+			Expected: []output{
+				{Path: "Name", Flag: "name", Env: "NAME", Key: "name"},
+				{Path: "Child.Size", Flag: "child.size", Env: "CHILD_SIZE", Key: "child.size"},
+			},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestField_Alloc(t *testing.T) {
+	type db struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		DB     *db
+		Backup *db
+	}
+	c := &config{}
+	for _, f := range Fields(c, ".") {
+		if !f.Nil {
+			t.Errorf("%s should be in a nil struct", f.Path)
+		}
+		if f.Path == "DB.Host" {
+			f.Alloc().SetString("localhost")
+		}
+	}
+	if c.DB == nil || c.DB.Host != "localhost" {
+		t.Errorf("DB.Host not set %+v", c.DB)
+	}
+	if c.Backup != nil {
+		t.Errorf("Backup should stay nil %+v", c.Backup)
+	}
+}
+
+func TestFormatKey(t *testing.T) {
+	type db struct {
+		Host string `json:"host" yaml:"db_host"`
+		Port int
+	}
+	type Embed struct {
+		Level int
+	}
+	type config struct {
+		DB     db `json:"database"`
+		Skip   db `json:"-" yaml:"-"`
+		Inline db `yaml:",inline"`
+		Embed
+	}
+	fn := func(tag string) ([]string, error) {
+		var keys []string
+		for _, f := range Fields(&config{}, ".") {
+			keys = append(keys, f.FormatKey(tag))
+		}
+		return keys, nil
+	}
+	cases := trial.Cases[string, []string]{
+		"json": {
+			Input:    "json",
+			Expected: []string{"database.host", "database.Port", "", "", "Inline.host", "Inline.Port", "Level"},
+		},
+		"yaml": {
+			Input:    "yaml",
+			Expected: []string{"db.db_host", "db.port", "", "", "db_host", "port", "embed.level"},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
@@ -2,39 +2,418 @@ package file
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"path/filepath"
 	"strings"
 
+	"github.com/hydronica/go-config/internal/encode"
 	"github.com/hydronica/go-config/internal/encode/env"
+	"github.com/hydronica/go-config/internal/encode/hcl"
+	"github.com/hydronica/go-config/internal/encode/ini"
+	"github.com/hydronica/go-config/internal/encode/properties"
 	"github.com/hydronica/toml"
 	"gopkg.in/yaml.v2"
 )
 
-// Load config from file, type is determined by the file extension
+// IncludeKey is the reserved config file key used to include other
+// config files. ie: include = ["db.toml", "secrets.yaml"]
+// The key is not reserved when the config has a field with the same key.
+const IncludeKey = "include"
+
+// ErrProfileNotFound is returned when the requested profile is not in the
+// config file or its includes. The base values are still loaded.
+var ErrProfileNotFound = errors.New("profile not found")
+
+// Load config from file, type is determined by the file extension.
+//
+// ${VAR} references in string values are expanded (see encode.ExpandStrict) using
+// the config's keys as named in the file (ie ${db.host}) and then the process env.
+//
+// Files listed under the IncludeKey are loaded before the values of the
+// including file, so the including file overrides its includes. Include paths
+// are relative to the including file and may be any supported format.
 func Load(f string, i interface{}) error {
-	switch strings.Trim(filepath.Ext(f), ".") {
-	case "toml":
-		_, err := toml.DecodeFile(f, i)
+	return Loader{}.Load(f, i)
+}
+
+// LoadProfile is the same as Load except the named profile section
+// of the file (and its includes) is merged on top of the base values.
+// ErrProfileNotFound is returned if neither the file nor its includes
+// have the profile. See ProfilesKey.
+func LoadProfile(f, profile string, i interface{}) error {
+	return Loader{}.LoadProfile(f, profile, i)
+}
+
+// Loader loads config files with the format options.
+// The zero value is the same as Load.
+type Loader struct {
+	// JSONC reads .json files the same as .jsonc and .json5 files
+	// allowing comments and trailing commas.
+	JSONC bool
+
+	// NoHCL rejects .hcl files and skips them in directories.
+	NoHCL bool
+
+	// Env decodes .env files with its settings (ie Prefix).
+	// env.LoadEnvFile is used when nil.
+	Env *env.Decoder
+
+	// NoInterpolate leaves ${VAR} references as is so a document
+	// from a remote server cannot read the local env.
+	NoInterpolate bool
+}
+
+// Load is the same as the package Load with the Loader's options.
+func (l Loader) Load(f string, i interface{}) error {
+	return l.LoadProfile(f, "", i)
+}
+
+// LoadProfile is the same as the package LoadProfile with the Loader's options.
+func (l Loader) LoadProfile(f, profile string, i interface{}) error {
+	found, err := l.load(f, profile, i, nil)
+	if err != nil {
 		return err
-	case "json":
-		b, err := ioutil.ReadFile(f)
+	}
+	return profileErr(found, profile, f)
+}
+
+// profileErr returns ErrProfileNotFound if a profile was requested but not found in src.
+func profileErr(found bool, profile, src string) error {
+	if profile == "" || found {
+		return nil
+	}
+	return fmt.Errorf("%w: %q in %s", ErrProfileNotFound, profile, src)
+}
+
+// load the file f after its includes. chain is the list of
+// files that included f and is used to detect include cycles.
+// found reports if the profile is in f or its includes.
+func (l Loader) load(f, profile string, i interface{}, chain []string) (found bool, err error) {
+	abs, err := filepath.Abs(f)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range chain {
+		if cAbs, _ := filepath.Abs(c); cAbs == abs {
+			return false, fmt.Errorf("include cycle %s -> %s", strings.Join(chain, " -> "), f)
+		}
+	}
+	chain = append(chain[:len(chain):len(chain)], f)
+
+	if err := l.enabled(l.format(f)); err != nil {
+		return false, includeErr(chain, err)
+	}
+	var includes []string
+	if !hasKey(i, IncludeKey) {
+		if includes, err = l.readIncludes(f); err != nil {
+			return false, includeErr(chain, err)
+		}
+	}
+	for _, inc := range includes {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(f), inc)
+		}
+		incFound, err := l.load(inc, profile, i, chain)
 		if err != nil {
-			return err
+			return false, err
+		}
+		found = found || incFound
+	}
+	before := snapshot(i)
+	fileFound, err := l.decode(f, profile, i)
+	if err != nil {
+		return false, includeErr(chain, err)
+	}
+	found = found || fileFound
+	// .env values are expanded as they are read
+	if filepath.Ext(f) == ".env" {
+		return found, nil
+	}
+	return found, includeErr(chain, l.interpolate(i, before, l.format(f)))
+}
+
+// Files returns the file f and the files it includes (see IncludeKey) in the
+// order they are loaded into i, so the last file has the highest precedence.
+// Files that cannot be read are left out.
+func (l Loader) Files(f string, i interface{}) []string {
+	return l.files(f, i, nil)
+}
+
+func (l Loader) files(f string, i interface{}, chain []string) []string {
+	for _, c := range chain {
+		if c == f {
+			return nil
+		}
+	}
+	chain = append(chain[:len(chain):len(chain)], f)
+	var files []string
+	if !hasKey(i, IncludeKey) {
+		includes, _ := l.readIncludes(f)
+		for _, inc := range includes {
+			if !filepath.IsAbs(inc) {
+				inc = filepath.Join(filepath.Dir(f), inc)
+			}
+			files = append(files, l.files(inc, i, chain)...)
+		}
+	}
+	return append(files, f)
+}
+
+// includeErr adds the include chain to errors of included files
+func includeErr(chain []string, err error) error {
+	if err == nil || len(chain) < 2 {
+		return err
+	}
+	return fmt.Errorf("%w (included from %s)", err, strings.Join(chain[:len(chain)-1], " -> "))
+}
+
+// readIncludes returns the files listed under the IncludeKey of f.
+// Only called when the config does not have a field with the IncludeKey.
+func (l Loader) readIncludes(f string) ([]string, error) {
+	var inc struct {
+		Include []string `toml:"include" json:"include" yaml:"include" env:"INCLUDE"`
+	}
+	var err error
+	switch l.format(f) {
+	case "toml":
+		_, err = toml.DecodeFile(f, &inc)
+	case "json", "jsonc":
+		var b []byte
+		if b, err = ioutil.ReadFile(f); err == nil {
+			if b, err = l.standardJSON(b, strings.Trim(filepath.Ext(f), ".")); err == nil {
+				err = json.Unmarshal(b, &inc)
+			}
 		}
-		return json.Unmarshal(b, i)
 	case "yaml", "yml":
+		var b []byte
+		if b, err = ioutil.ReadFile(f); err == nil {
+			err = yaml.Unmarshal(b, &inc)
+		}
+	case "ini":
+		var b []byte
+		if b, err = ioutil.ReadFile(f); err == nil {
+			err = ini.Unmarshal(b, &inc)
+		}
+	case "properties":
+		var b []byte
+		if b, err = ioutil.ReadFile(f); err == nil {
+			err = properties.Unmarshal(b, &inc)
+		}
+	case "hcl":
+		var b []byte
+		if b, err = ioutil.ReadFile(f); err == nil {
+			err = hcl.Unmarshal(b, &inc)
+		}
+	case "env":
+		err = env.LoadEnvFile(f, &inc)
+	}
+	return inc.Include, err
+}
+
+// decode the file f into i based on the file extension
+// followed by the profile section if a profile is provided.
+// found reports if the file has the profile, .env files have no profiles.
+func (l Loader) decode(f, profile string, i interface{}) (found bool, err error) {
+	switch l.format(f) {
+	case "env":
+		if l.Env != nil {
+			return false, l.Env.LoadFile(f, i)
+		}
+		return false, env.LoadEnvFile(f, i)
+	case "toml", "json", "jsonc", "yaml", "yml", "ini", "properties", "hcl":
 		b, err := ioutil.ReadFile(f)
 		if err != nil {
-			return err
+			return false, err
 		}
-		return yaml.Unmarshal(b, i)
-	case "env":
-		return env.LoadEnvFile(f, i)
+		// the extension rather than the format so json5 files are checked (see checkJSON5)
+		return l.decodeBytes(b, strings.Trim(filepath.Ext(f), "."), profile, i)
+	default:
+		return false, fmt.Errorf("unknown file type %s", filepath.Ext(f))
+	}
+}
+
+// enabled returns an error if the format is turned off by the Loader's options.
+func (l Loader) enabled(format string) error {
+	if format == "hcl" && l.NoHCL {
+		return fmt.Errorf("hcl files are disabled")
+	}
+	return nil
+}
+
+// format of the file f from its extension (see formatOf).
+func (l Loader) format(f string) string {
+	return l.formatOf(strings.Trim(filepath.Ext(f), "."))
+}
+
+// formatOf returns the decoder format of the extension. json5 and
+// json when JSONC is enabled are decoded as jsonc.
+func (l Loader) formatOf(ext string) string {
+	if ext == "json5" || (ext == "json" && l.JSONC) {
+		return "jsonc"
+	}
+	return ext
+}
+
+// standardJSON returns the json document b of the extension (json, jsonc, json5)
+// as standard json. Comments and trailing commas are removed from jsonc documents
+// and json5 documents are rejected if they use other json5 syntax (see checkJSON5).
+func (l Loader) standardJSON(b []byte, ext string) ([]byte, error) {
+	if l.formatOf(ext) != "jsonc" {
+		return b, nil
+	}
+	b, err := standardJSON(b)
+	if err != nil || ext != "json5" {
+		return b, err
+	}
+	return b, checkJSON5(b)
+}
+
+// decodeBytes decodes the document b of the format (toml, json, jsonc, yaml, ini, properties, hcl)
+// into i followed by the profile section if a profile is provided.
+// found reports if the document has the profile.
+func (l Loader) decodeBytes(b []byte, format, profile string, i interface{}) (found bool, err error) {
+	ext := format
+	format = l.formatOf(format)
+	if err := l.enabled(format); err != nil {
+		return false, err
+	}
+	switch format {
+	case "toml":
+		if _, err := toml.Decode(string(b), i); err != nil {
+			return false, err
+		}
+		return decodeTomlProfile(b, profile, i)
+	case "json", "jsonc":
+		if b, err = l.standardJSON(b, ext); err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(b, i); err != nil {
+			return false, err
+		}
+		return decodeJsonProfile(b, profile, i)
+	case "yaml", "yml":
+		return decodeYaml(b, profile, i)
+	case "ini", "properties":
+		parse := ini.Parse
+		if format == "properties" {
+			parse = properties.Parse
+		}
+		values, err := parse(b)
+		if err != nil {
+			return false, err
+		}
+		if err := encode.UnmarshalKeys(values, i); err != nil {
+			return false, err
+		}
+		keys, found := profileKeys(values, profile)
+		return found, encode.UnmarshalKeys(keys, i)
+	case "hcl":
+		return decodeHCL(b, profile, i)
 	default:
-		return fmt.Errorf("unknown file type %s", filepath.Ext(f))
+		return false, fmt.Errorf("unknown format %s", format)
+	}
+}
+
+// LoadBytes decodes the config document b of the format (toml, json, jsonc, yaml, ini, properties, hcl)
+// the same as LoadProfile except includes are not supported. ErrProfileNotFound
+// is returned if the profile is not in the document.
+func LoadBytes(b []byte, format, profile string, i interface{}) error {
+	return Loader{}.LoadBytes(b, format, profile, i)
+}
+
+// LoadBytes is the same as the package LoadBytes with the Loader's options.
+func (l Loader) LoadBytes(b []byte, format, profile string, i interface{}) error {
+	before := snapshot(i)
+	found, err := l.decodeBytes(b, format, profile, i)
+	if err != nil {
+		return err
+	}
+	if err := l.interpolate(i, before, format); err != nil {
+		return err
+	}
+	return profileErr(found, profile, format+" document")
+}
+
+// interpolate the values set by the document unless NoInterpolate is set (see interpolate).
+func (l Loader) interpolate(i interface{}, before map[string]string, format string) error {
+	if l.NoInterpolate {
+		return nil
+	}
+	return interpolate(i, before, format)
+}
+
+// extensions that can be loaded by Load
+var extensions = map[string]bool{
+	"toml":       true,
+	"json":       true,
+	"jsonc":      true,
+	"json5":      true,
+	"yaml":       true,
+	"yml":        true,
+	"ini":        true,
+	"env":        true,
+	"properties": true,
+	"hcl":        true,
+}
+
+// Supported reports if the file extension of f can be loaded.
+func Supported(f string) bool {
+	return Loader{}.Supported(f)
+}
+
+// Supported is the same as the package Supported with the Loader's options.
+func (l Loader) Supported(f string) bool {
+	ext := strings.Trim(filepath.Ext(f), ".")
+	return extensions[ext] && l.enabled(ext) == nil
+}
+
+// DirFiles returns the path of every supported config file in dir in lexical order.
+// Files with an unknown extension or a disabled format are skipped with a warning
+// while hidden files and sub directories are ignored.
+func DirFiles(dir string) ([]string, error) {
+	return Loader{}.DirFiles(dir)
+}
+
+// DirFiles is the same as the package DirFiles with the Loader's options.
+func (l Loader) DirFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if !l.Supported(e.Name()) {
+			reason := "unknown file type " + filepath.Ext(e.Name())
+			if err := l.enabled(strings.Trim(filepath.Ext(e.Name()), ".")); err != nil {
+				reason = err.Error()
+			}
+			log.Printf("skipping %s: %s", filepath.Join(dir, e.Name()), reason)
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	return files, nil
+}
+
+// LoadDir loads every supported config file in dir in lexical order.
+// Later files override the values of earlier files.
+func LoadDir(dir string, i interface{}) error {
+	files, err := DirFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := Load(f, i); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // todo: issue how to properly handle custom formats for time.Time 'fmt' in json, yaml and toml
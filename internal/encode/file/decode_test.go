@@ -1,7 +1,12 @@
 package file
 
 import (
+	"bytes"
 	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -46,6 +51,32 @@ func TestLoad(t *testing.T) {
 				//Dura: 10 * time.Second, //TODO add support
 			},
 		},
+		"jsonc": {
+			Input: filePath + "test.jsonc",
+			Expected: &SimpleStruct{
+				Name:   "jsonc",
+				Value:  10,
+				Enable: true,
+				Time:   trial.TimeDay("2010-08-10"),
+			},
+		},
+		"json5": {
+			Input: filePath + "test.json5",
+			Expected: &SimpleStruct{
+				Name:   "json5",
+				Value:  10,
+				Enable: true,
+				Time:   trial.TimeDay("2010-08-10"),
+			},
+		},
+		"json5 unquoted key": {
+			Input:       filePath + "unquoted.json5",
+			ExpectedErr: errors.New("json5: line 3: unsupported json5 syntax: unquoted key or value name"),
+		},
+		"json with comments": {
+			Input:     filePath + "comments.json",
+			ShouldErr: true,
+		},
 		"yaml": {
 			Input: filePath + "test.yaml",
 			Expected: &SimpleStruct{
@@ -56,6 +87,39 @@ func TestLoad(t *testing.T) {
 				Time:   trial.TimeDay("2010-08-10"),
 			},
 		},
+		"ini": {
+			Input: filePath + "test.ini",
+			Expected: &SimpleStruct{
+				Name:    "ini",
+				Value:   10,
+				Enable:  true,
+				Float64: 99.9,
+				Dura:    10 * time.Second,
+				Time:    trial.TimeDay("2010-08-10"),
+			},
+		},
+		"properties": {
+			Input: filePath + "test.properties",
+			Expected: &SimpleStruct{
+				Name:    "properties",
+				Value:   10,
+				Enable:  true,
+				Float64: 99.9,
+				Dura:    10 * time.Second,
+				Time:    trial.TimeDay("2010-08-10"),
+			},
+		},
+		"hcl": {
+			Input: filePath + "test.hcl",
+			Expected: &SimpleStruct{
+				Name:    "hcl",
+				Value:   10,
+				Enable:  true,
+				Float64: 99.9,
+				Dura:    10 * time.Second,
+				Time:    trial.TimeDay("2010-08-10"),
+			},
+		},
 		"env": {
 			Input: filePath + ".env",
 			Expected: &SimpleStruct{
@@ -78,3 +142,337 @@ func TestLoad(t *testing.T) {
 	}
 	trial.New(fn, cases).Test(t)
 }
+
+func TestLoader_JSONC(t *testing.T) {
+	c := &SimpleStruct{}
+	if err := (Loader{JSONC: true}).Load(filePath+"comments.json", c); err != nil {
+		t.Fatal(err)
+	}
+	if eq, diff := trial.Equal(c, &SimpleStruct{Name: "comments", Value: 10}); !eq {
+		t.Error(diff)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	fn := func(in string) (*SimpleStruct, error) {
+		c := &SimpleStruct{Enable: true}
+		err := LoadDir(in, c)
+		return c, err
+	}
+	cases := trial.Cases[string, *SimpleStruct]{
+		"conf.d": {
+			Input: filePath + "conf.d",
+			Expected: &SimpleStruct{
+				Name:   "override",
+				Value:  20,
+				Enable: true,
+			},
+		},
+		"missing dir": {
+			Input:       filePath + "missing.d",
+			ExpectedErr: errors.New("no such file or directory"),
+		},
+	}
+	trial.New(fn, cases).Test(t)
+}
+
+func TestDirFiles(t *testing.T) {
+	files, err := DirFiles(filePath + "conf.d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{filePath + "conf.d/00-base.toml", filePath + "conf.d/10-override.yaml"}
+	if eq, diff := trial.Equal(files, exp); !eq {
+		t.Error(diff)
+	}
+}
+
+func TestLoader_DirFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"a.hcl", "b.toml", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	files, err := Loader{NoHCL: true}.DirFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eq, diff := trial.Equal(files, []string{filepath.Join(dir, "b.toml")}); !eq {
+		t.Error(diff)
+	}
+	for _, s := range []string{"a.hcl: hcl files are disabled", "c.txt: unknown file type .txt"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("log %q does not contain %q", buf.String(), s)
+		}
+	}
+}
+
+func TestLoad_include(t *testing.T) {
+	fn := func(in string) (*SimpleStruct, error) {
+		c := &SimpleStruct{}
+		err := Load(in, c)
+		return c, err
+	}
+	cases := trial.Cases[string, *SimpleStruct]{
+		"nested includes": {
+			Input: filePath + "include/main.toml",
+			Expected: &SimpleStruct{
+				Name:   "main",
+				Value:  5,
+				Enable: true,
+			},
+		},
+		"cycle": {
+			Input:       filePath + "include/cycle-a.toml",
+			ExpectedErr: errors.New("include cycle ../../../test/include/cycle-a.toml -> ../../../test/include/cycle-b.yaml -> ../../../test/include/cycle-a.toml"),
+		},
+		"missing include": {
+			Input:       filePath + "include/bad-include.toml",
+			ExpectedErr: errors.New("no such file or directory (included from ../../../test/include/bad-include.toml)"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestLoadProfile(t *testing.T) {
+	type input struct {
+		file    string
+		profile string
+	}
+	fn := func(in input) (*SimpleStruct, error) {
+		c := &SimpleStruct{}
+		err := LoadProfile(filePath+in.file, in.profile, c)
+		return c, err
+	}
+	cases := trial.Cases[input, *SimpleStruct]{
+		"toml base": {
+			Input:    input{file: "profile.toml"},
+			Expected: &SimpleStruct{Name: "base", Value: 10},
+		},
+		"toml profile": {
+			Input:    input{file: "profile.toml", profile: "prod"},
+			Expected: &SimpleStruct{Name: "prod", Value: 10},
+		},
+		"toml unknown profile": {
+			Input:       input{file: "profile.toml", profile: "dev"},
+			ExpectedErr: ErrProfileNotFound,
+		},
+		"json profile": {
+			Input:    input{file: "profile.json", profile: "prod"},
+			Expected: &SimpleStruct{Name: "prod", Value: 10},
+		},
+		"ini profile": {
+			Input:    input{file: "profile.ini", profile: "stage"},
+			Expected: &SimpleStruct{Name: "stage", Value: 20},
+		},
+		"properties profile": {
+			Input:    input{file: "profile.properties", profile: "stage"},
+			Expected: &SimpleStruct{Name: "stage", Value: 20},
+		},
+		"hcl profile": {
+			Input:    input{file: "profile.hcl", profile: "stage"},
+			Expected: &SimpleStruct{Name: "stage", Value: 20},
+		},
+		"yaml base documents": {
+			Input:    input{file: "profile.yaml"},
+			Expected: &SimpleStruct{Name: "base", Value: 10, Enable: true},
+		},
+		"yaml profile section": {
+			Input:    input{file: "profile.yaml", profile: "stage"},
+			Expected: &SimpleStruct{Name: "stage", Value: 20, Enable: true},
+		},
+		"yaml profile document": {
+			Input:    input{file: "profile.yaml", profile: "prod"},
+			Expected: &SimpleStruct{Name: "prod", Value: 10, Enable: true},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestLoad_reservedKeys(t *testing.T) {
+	type config struct {
+		Name    string
+		Profile string
+		Include []string
+	}
+	type input struct {
+		file    string
+		doc     string
+		profile string
+	}
+	fn := func(in input) (*config, error) {
+		c := &config{}
+		if in.file != "" {
+			return c, Load(filePath+in.file, c)
+		}
+		return c, LoadBytes([]byte(in.doc), "yaml", in.profile, c)
+	}
+	cases := trial.Cases[input, *config]{
+		"yaml profile field": {
+			Input:    input{doc: "name: app\nprofile: prod\n"},
+			Expected: &config{Name: "app", Profile: "prod"},
+		},
+		"yaml multi-document profile field": {
+			Input:    input{doc: "name: app\n---\nprofile: prod\n"},
+			Expected: &config{Name: "app", Profile: "prod"},
+		},
+		"yaml missing profile": {
+			Input:       input{doc: "name: app\n", profile: "prod"},
+			ExpectedErr: ErrProfileNotFound,
+		},
+		"include field": {
+			Input:    input{file: "include/main.toml"},
+			Expected: &config{Name: "main", Include: []string{"db.yaml"}},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestLoad_interpolate(t *testing.T) {
+	type config struct {
+		Name    string
+		URL     string
+		Default string
+	}
+	os.Setenv("GO_CONFIG_TEST_HOST", "myhost")
+	defer os.Unsetenv("GO_CONFIG_TEST_HOST")
+
+	c := &config{Default: "${GO_CONFIG_TEST_HOST}"}
+	if err := Load(filePath+"interpolate.toml", c); err != nil {
+		t.Fatal(err)
+	}
+	exp := &config{
+		Name:    "myhost:8080",
+		URL:     "http://myhost:8080/${literal}",
+		Default: "${GO_CONFIG_TEST_HOST}", // not from the file
+	}
+	if eq, diff := trial.Equal(c, exp); !eq {
+		t.Error(diff)
+	}
+}
+
+func TestLoad_interpolateOnce(t *testing.T) {
+	type config struct {
+		A string
+		B string
+	}
+	os.Setenv("GO_CONFIG_TEST_HOST", "myhost")
+	defer os.Unsetenv("GO_CONFIG_TEST_HOST")
+
+	// values of earlier files are already expanded and are not expanded again
+	c := &config{}
+	if err := LoadBytes([]byte(`a = "$${GO_CONFIG_TEST_HOST}"`), "toml", "", c); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadBytes([]byte(`b = "${a}"`), "toml", "", c); err != nil {
+		t.Fatal(err)
+	}
+	exp := &config{A: "${GO_CONFIG_TEST_HOST}", B: "${GO_CONFIG_TEST_HOST}"}
+	if eq, diff := trial.Equal(c, exp); !eq {
+		t.Error(diff)
+	}
+}
+
+func TestLoadBytes_interpolateKeys(t *testing.T) {
+	type config struct {
+		Host   string
+		DBHost string `yaml:"db_host" json:"db_host"`
+		URL    string
+	}
+	type input struct {
+		doc    string
+		format string
+	}
+	fn := func(in input) (*config, error) {
+		c := &config{}
+		err := LoadBytes([]byte(in.doc), in.format, "", c)
+		return c, err
+	}
+	cases := trial.Cases[input, *config]{
+		"json field name": {
+			Input:    input{doc: `{"Host":"h1","URL":"http://${Host}/"}`, format: "json"},
+			Expected: &config{Host: "h1", URL: "http://h1/"},
+		},
+		"yaml tag": {
+			Input:    input{doc: "db_host: h2\nurl: \"http://${db_host}/\"\n", format: "yaml"},
+			Expected: &config{DBHost: "h2", URL: "http://h2/"},
+		},
+		"toml key": {
+			Input:    input{doc: "dbhost = \"h3\"\nurl = \"http://${dbhost}/\"", format: "toml"},
+			Expected: &config{DBHost: "h3", URL: "http://h3/"},
+		},
+		"unknown reference": {
+			Input:       input{doc: `{"URL":"http://${GO_CONFIG_TEST_UNSET}/"}`, format: "json"},
+			ExpectedErr: errors.New("url: ${GO_CONFIG_TEST_UNSET} is not set"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestLoadBytes(t *testing.T) {
+	type input struct {
+		doc     string
+		format  string
+		profile string
+	}
+	fn := func(in input) (*SimpleStruct, error) {
+		c := &SimpleStruct{}
+		err := LoadBytes([]byte(in.doc), in.format, in.profile, c)
+		return c, err
+	}
+	cases := trial.Cases[input, *SimpleStruct]{
+		"json": {
+			Input:    input{doc: `{"name":"json","value":5}`, format: "json"},
+			Expected: &SimpleStruct{Name: "json", Value: 5},
+		},
+		"yaml profile": {
+			Input:    input{doc: "name: base\nprofiles:\n  prod:\n    name: prod\n", format: "yaml", profile: "prod"},
+			Expected: &SimpleStruct{Name: "prod"},
+		},
+		"toml": {
+			Input:    input{doc: "name = \"toml\"\nenable = true", format: "toml"},
+			Expected: &SimpleStruct{Name: "toml", Enable: true},
+		},
+		"jsonc": {
+			Input:    input{doc: "{\"name\": \"jsonc\", // comment\n\"value\": 5,}", format: "jsonc"},
+			Expected: &SimpleStruct{Name: "jsonc", Value: 5},
+		},
+		"json5": {
+			Input:    input{doc: "{\"name\": \"json5\", /* comment */ \"value\": -55,}", format: "json5"},
+			Expected: &SimpleStruct{Name: "json5", Value: -55},
+		},
+		"json5 unquoted key": {
+			Input:       input{doc: "{\n  name: \"json5\"\n}", format: "json5"},
+			ExpectedErr: errors.New("json5: line 2: unsupported json5 syntax: unquoted key or value name"),
+		},
+		"json5 single quotes": {
+			Input:       input{doc: "{\"name\": 'json5'}", format: "json5"},
+			ExpectedErr: errors.New("json5: line 1: unsupported json5 syntax: single quoted string"),
+		},
+		"json5 hex": {
+			Input:       input{doc: "{\"value\": 0x10}", format: "json5"},
+			ExpectedErr: errors.New("unsupported json5 syntax: hexadecimal number 0x10"),
+		},
+		"json5 infinity": {
+			Input:       input{doc: "{\"value\": -Infinity}", format: "json5"},
+			ExpectedErr: errors.New("unsupported json5 syntax: Infinity"),
+		},
+		"json5 decimal point": {
+			Input:       input{doc: "{\"value\": .5}", format: "json5"},
+			ExpectedErr: errors.New("unsupported json5 syntax: leading decimal point"),
+		},
+		"ini profile": {
+			Input:    input{doc: "name = base\n[profiles.prod]\nname = prod\n", format: "ini", profile: "prod"},
+			Expected: &SimpleStruct{Name: "prod"},
+		},
+		"unknown format": {
+			Input:     input{doc: "NAME=env", format: "env"},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
@@ -1,28 +1,68 @@
 package file
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
 
 	"github.com/hydronica/toml"
 	"gopkg.in/yaml.v2"
 
+	"github.com/hydronica/go-config/internal/encode"
 	"github.com/hydronica/go-config/internal/encode/env"
+	"github.com/hydronica/go-config/internal/encode/hcl"
+	"github.com/hydronica/go-config/internal/encode/ini"
+	"github.com/hydronica/go-config/internal/encode/properties"
 )
 
 // Encode a config to a file based on the ext passed in
-// Note: only toml supports comments
+// Note: only toml, jsonc, ini, properties and hcl support comments.
+// Required fields are marked in all formats except json.
+//
+// A '$' in a string value is written as "$$" so it is not expanded when
+// the file is loaded (the env encoder quotes and escapes its own values).
 func Encode(w io.Writer, i interface{}, ext string) error {
+	if ext != "env" {
+		i = encode.Escape(i)
+	}
 	switch ext {
 	case "toml":
-		enc := toml.NewEncoder(w)
-		return enc.Encode(i)
+		buf := &bytes.Buffer{}
+		if err := toml.NewEncoder(buf).Encode(i); err != nil {
+			return err
+		}
+		_, err := w.Write(markRequired(buf.Bytes(), i))
+		return err
 	case "yaml", "yml":
 		b, err := yaml.Marshal(i)
 		if err != nil {
 			return err
 		}
+		_, err = w.Write(markYAMLRequired(b, i))
+		return err
+	case "ini":
+		b, err := ini.Marshal(i)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case "properties":
+		b, err := properties.Marshal(i)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case "hcl":
+		b, err := hcl.Marshal(i)
+		if err != nil {
+			return err
+		}
 		_, err = w.Write(b)
 		return err
 	case "env":
@@ -39,7 +79,111 @@ func Encode(w io.Writer, i interface{}, ext string) error {
 		}
 		_, err = w.Write(b)
 		return err
+	case "jsonc":
+		b, err := json.MarshalIndent(i, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(markJSONComments(b, i))
+		return err
 	default:
 		return fmt.Errorf("unsupported config extension %s", ext)
 	}
 }
+
+// markRequired appends a "# required" comment to each toml key
+// of a field with the `req:"true"` tag.
+func markRequired(b []byte, i interface{}) []byte {
+	required := make(map[string]bool)
+	for _, f := range encode.Fields(i, ".") {
+		if f.Key != "" && f.Struct.Tag.Get(encode.ReqTag) == "true" {
+			required[strings.ToLower(f.Key)] = true
+		}
+	}
+	if len(required) == 0 {
+		return b
+	}
+
+	out := &bytes.Buffer{}
+	table := ""
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "["):
+			table = strings.Trim(trimmed, "[]")
+		case strings.HasPrefix(trimmed, "#"):
+		case strings.Contains(trimmed, "="):
+			key := strings.Trim(strings.TrimSpace(trimmed[:strings.Index(trimmed, "=")]), `"`)
+			if table != "" {
+				key = table + "." + key
+			}
+			if required[strings.ToLower(key)] {
+				line += " # required"
+			}
+		}
+		out.WriteString(line + "\n")
+	}
+	return out.Bytes()
+}
+
+// markYAMLRequired appends a "# required" comment to each yaml key
+// of a field with the `req:"true"` tag.
+func markYAMLRequired(b []byte, i interface{}) []byte {
+	fields := formatFields(i, "yaml")
+
+	type parent struct {
+		indent int
+		key    string
+	}
+	var open []parent // the mappings the current line is nested in
+	out := &bytes.Buffer{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		end := strings.Index(trimmed, ":")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") || end <= 0 {
+			out.WriteString(line + "\n")
+			continue
+		}
+		for len(open) > 0 && open[len(open)-1].indent >= indent {
+			open = open[:len(open)-1]
+		}
+		keys := make([]string, 0, len(open)+1)
+		for _, p := range open {
+			keys = append(keys, p.key)
+		}
+		key := strings.Join(append(keys, trimmed[:end]), ".")
+		if sField, ok := fields[key]; ok && sField.Tag.Get(encode.ReqTag) == "true" {
+			line += " # required"
+		}
+		if trimmed[end+1:] == "" {
+			open = append(open, parent{indent: indent, key: trimmed[:end]})
+		}
+		out.WriteString(line + "\n")
+	}
+	return out.Bytes()
+}
+
+// formatFields maps the dotted key of each field and nested struct of i for
+// the struct tag of a format (see encode.FormatKey) to the struct field.
+func formatFields(i interface{}, tag string) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for _, f := range encode.Fields(i, ".") {
+		parent := ""
+		for n := range f.Parents {
+			// embedded structs are promoted and have the key of their parent
+			if key := encode.FormatKey(f.Parents[:n+1], tag); key != "" && key != parent {
+				fields[key] = f.Parents[n]
+				parent = key
+			}
+		}
+		if key := f.FormatKey(tag); key != "" {
+			fields[key] = f.Struct
+		}
+	}
+	return fields
+}
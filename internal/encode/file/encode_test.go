@@ -0,0 +1,133 @@
+package file
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestEncode(t *testing.T) {
+	type db struct {
+		Host string `req:"true"`
+		Port int
+	}
+	type config struct {
+		Name string `req:"true" comment:"app name"`
+		DB   db
+	}
+	type input struct {
+		config interface{}
+		ext    string
+	}
+	fn := func(in input) (string, error) {
+		buf := &bytes.Buffer{}
+		err := Encode(buf, in.config, in.ext)
+		return buf.String(), err
+	}
+	cases := trial.Cases[input, string]{
+		"toml required": {
+			Input: input{
+				config: &config{Name: "app", DB: db{Port: 5432}},
+				ext:    "toml",
+			},
+			Expected: "# app name\nName = \"app\" # required\n\n[DB]\n  Host = \"\" # required\n  Port = 5432\n",
+		},
+		"yaml required": {
+			Input: input{
+				config: &config{Name: "app", DB: db{Port: 5432}},
+				ext:    "yaml",
+			},
+			Expected: "name: app # required\ndb:\n  host: \"\" # required\n  port: 5432\n",
+		},
+		"yaml nested required": {
+			Input: input{
+				config: &struct {
+					A struct {
+						Inner struct {
+							Key string `req:"true"`
+						}
+					}
+				}{},
+				ext: "yaml",
+			},
+			Expected: "a:\n  inner:\n    key: \"\" # required\n",
+		},
+		"env required": {
+			Input: input{
+				config: &config{Name: "app", DB: db{Port: 5432}},
+				ext:    "env",
+			},
+			Expected: "NAME=app # required\nDB_HOST=\"\" # required\nDB_PORT=5432\n",
+		},
+		"ini comments": {
+			Input: input{
+				config: &config{Name: "app", DB: db{Port: 5432}},
+				ext:    "ini",
+			},
+			Expected: "; app name\nname = app ; required\n\n[db]\nhost = \"\" ; required\nport = 5432\n",
+		},
+		"properties comments": {
+			Input: input{
+				config: &config{Name: "app", DB: db{Port: 5432}},
+				ext:    "properties",
+			},
+			Expected: "# app name\n# required\nname=app\n\n# required\ndb.host=\ndb.port=5432\n",
+		},
+		"hcl comments": {
+			Input: input{
+				config: &config{Name: "app", DB: db{Port: 5432}},
+				ext:    "hcl",
+			},
+			Expected: "# app name\nname = \"app\" # required\n\ndb {\n  host = \"\" # required\n  port = 5432\n}\n",
+		},
+		"jsonc comments": {
+			Input: input{
+				config: &config{Name: "app", DB: db{Port: 5432}},
+				ext:    "jsonc",
+			},
+			Expected: "{\n  // app name\n  \"Name\": \"app\", // required\n  \"DB\": {\n    \"Host\": \"\", // required\n    \"Port\": 5432\n  }\n}\n",
+		},
+		"unknown": {
+			Input:     input{config: &config{}, ext: "xml"},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestEncode_escape(t *testing.T) {
+	type db struct {
+		Password string
+	}
+	type config struct {
+		Name  string
+		Tags  []string
+		DB    *db
+		Other string `toml:"other_name" yaml:"other_name" json:"other_name"`
+	}
+	fn := func(ext string) (*config, error) {
+		in := &config{Name: "${HOME}", Tags: []string{"a$b"}, DB: &db{Password: "pa$$${x}"}, Other: "$"}
+		buf := &bytes.Buffer{}
+		if err := Encode(buf, in, ext); err != nil {
+			return nil, err
+		}
+		if in.Name != "${HOME}" {
+			t.Errorf("config changed by Encode %q", in.Name)
+		}
+		c := &config{}
+		err := LoadBytes(buf.Bytes(), ext, "", c)
+		return c, err
+	}
+	exp := &config{Name: "${HOME}", Tags: []string{"a$b"}, DB: &db{Password: "pa$$${x}"}, Other: "$"}
+	cases := trial.Cases[string, *config]{
+		"toml":       {Input: "toml", Expected: exp},
+		"yaml":       {Input: "yaml", Expected: exp},
+		"json":       {Input: "json", Expected: exp},
+		"jsonc":      {Input: "jsonc", Expected: exp},
+		"ini":        {Input: "ini", Expected: exp},
+		"properties": {Input: "properties", Expected: exp},
+		"hcl":        {Input: "hcl", Expected: exp},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
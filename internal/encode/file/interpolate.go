@@ -0,0 +1,93 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// snapshot returns the current value of every field by field path.
+func snapshot(i interface{}) map[string]string {
+	values := make(map[string]string)
+	for _, f := range encode.Fields(i, ".") {
+		values[f.Path] = encode.FieldString(f.Value, f.Struct)
+	}
+	return values
+}
+
+// refKey returns the key used to reference the field in a document of the
+// format. It is the dotted key as named by the format's decoder (ie the json
+// or yaml tag) and is lowercase for the formats that match keys case-insensitively.
+func refKey(f encode.Field, format string) string {
+	switch format {
+	case "yaml", "yml":
+		return f.FormatKey("yaml")
+	case "json", "jsonc", "json5":
+		return strings.ToLower(f.FormatKey("json"))
+	}
+	return strings.ToLower(f.Key)
+}
+
+// interpolate expands ${VAR} references (see encode.ExpandStrict) in the string
+// values that were changed since the before snapshot. References are resolved
+// against the config's keys as named in a document of the format (ie ${db.host}
+// or the json/yaml tag) and then the process env. A reference to neither is an error.
+// Each value is expanded once: keys that were not changed use their current
+// value as it was already expanded by an earlier file (or is a default).
+// A key that references itself (directly or through other keys) is empty.
+func interpolate(i interface{}, before map[string]string, format string) error {
+	fields := encode.Fields(i, ".")
+	values := snapshot(i)
+	paths := make(map[string]string) // field path by reference key
+	for _, f := range fields {
+		if k := refKey(f, format); k != "" {
+			paths[k] = f.Path
+		}
+	}
+	expanded := make(map[string]string)
+	resolving := make(map[string]bool)
+	var lookup func(string) (string, bool)
+	lookup = func(k string) (string, bool) {
+		key := k
+		if format != "yaml" && format != "yml" {
+			key = strings.ToLower(k)
+		}
+		path, ok := paths[key]
+		if !ok {
+			return os.LookupEnv(k)
+		}
+		v := values[path]
+		if before[path] == v {
+			return v, true
+		}
+		if s, ok := expanded[path]; ok {
+			return s, true
+		}
+		// references to other keys use their expanded value
+		if resolving[path] {
+			return "", true
+		}
+		resolving[path] = true
+		defer delete(resolving, path)
+		s, err := encode.ExpandStrict(v, lookup)
+		if err != nil {
+			return v, true
+		}
+		expanded[path] = s
+		return s, true
+	}
+	for _, f := range fields {
+		if before[f.Path] == values[f.Path] {
+			continue
+		}
+		err := encode.ReplaceStrings(f.Value, func(s string) (string, error) {
+			return encode.ExpandStrict(s, lookup)
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", refKey(f, format), err)
+		}
+	}
+	return nil
+}
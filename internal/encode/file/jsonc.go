@@ -0,0 +1,172 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// standardJSON converts the JSONC or JSON5 document b to standard json by
+// removing the '//' and '/* */' comments and the trailing commas of objects
+// and arrays. Comment characters within strings are kept.
+func standardJSON(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	comma := -1 // index in out of a comma that may be trailing
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '"':
+			start := i
+			for i++; i < len(b) && b[i] != '"'; i++ {
+				if b[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(b) {
+				return nil, fmt.Errorf("jsonc: unterminated string")
+			}
+			out = append(out, b[start:i+1]...)
+			comma = -1
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			i-- // keep the newline
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := bytes.Index(b[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, fmt.Errorf("jsonc: unterminated comment")
+			}
+			// keep the newlines so json errors report the right line
+			out = append(out, bytes.Repeat([]byte("\n"), bytes.Count(b[i:i+end+2], []byte("\n")))...)
+			i += end + 3
+		case c == ',':
+			comma = len(out)
+			out = append(out, c)
+		case (c == '}' || c == ']') && comma >= 0:
+			out[comma] = ' '
+			comma = -1
+			out = append(out, c)
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			out = append(out, c)
+		default:
+			comma = -1
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// checkJSON5 returns an error naming the first json5 syntax of the standard
+// json document b (see standardJSON) that is not supported: single quoted
+// strings, unquoted keys, hexadecimal numbers, NaN, Infinity, a leading '+'
+// and a leading or trailing decimal point.
+func checkJSON5(b []byte) error {
+	line := 1
+	unsupported := func(syntax string) error {
+		return fmt.Errorf("json5: line %d: unsupported json5 syntax: %s", line, syntax)
+	}
+	isWord := func(c byte) bool {
+		return c == '_' || c == '$' || c == '.' || c == '+' || c == '-' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+	}
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '\n':
+			line++
+		case c == '"':
+			for i++; i < len(b) && b[i] != '"'; i++ {
+				if b[i] == '\\' {
+					i++
+				}
+			}
+		case c == '\'':
+			return unsupported("single quoted string")
+		case c == '+':
+			return unsupported("leading '+'")
+		case c == '.':
+			return unsupported("leading decimal point")
+		case isWord(c) && c != '-':
+			start := i
+			for i+1 < len(b) && isWord(b[i+1]) {
+				i++
+			}
+			word := string(b[start : i+1])
+			switch {
+			case word == "true" || word == "false" || word == "null":
+			case word == "NaN" || word == "Infinity":
+				return unsupported(word)
+			case strings.HasPrefix(word, "0x") || strings.HasPrefix(word, "0X"):
+				return unsupported("hexadecimal number " + word)
+			case '0' <= c && c <= '9':
+				if strings.HasSuffix(word, ".") {
+					return unsupported("trailing decimal point " + word)
+				}
+			default:
+				return unsupported("unquoted key or value " + word)
+			}
+		}
+	}
+	return nil
+}
+
+// markJSONComments adds the comment tag of each field as a '//' comment above
+// its key in the indented json document b and a trailing '// required' comment
+// to required fields.
+func markJSONComments(b []byte, i interface{}) []byte {
+	comments := formatFields(i, "json")
+
+	out := &bytes.Buffer{}
+	var path []string // keys of the open objects, "" for arrays
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := sc.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "}") || strings.HasPrefix(trimmed, "]") {
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+
+		key := ""
+		if strings.HasPrefix(trimmed, `"`) {
+			if end := strings.Index(trimmed, `": `); end > 0 {
+				json.Unmarshal([]byte(trimmed[:end+1]), &key)
+			}
+		}
+		sField, ok := comments[strings.Join(append(path, key), ".")]
+		if key != "" && ok && !contains(path, "") {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+			if c := sField.Tag.Get(encode.DescTag); c != "" {
+				out.WriteString(indent + "// " + c + "\n")
+			}
+			if sField.Tag.Get(encode.ReqTag) == "true" {
+				line += " // required"
+			}
+		}
+		out.WriteString(line + "\n")
+
+		switch {
+		case strings.HasSuffix(trimmed, "{") && key != "":
+			path = append(path, key)
+		case trimmed == "{" && len(path) == 0:
+			// the root object
+		case strings.HasSuffix(trimmed, "{"), strings.HasSuffix(trimmed, "["):
+			path = append(path, "")
+		}
+	}
+	return out.Bytes()
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
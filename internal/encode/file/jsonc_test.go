@@ -0,0 +1,71 @@
+package file
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestStandardJSON(t *testing.T) {
+	fn := func(in string) (string, error) {
+		b, err := standardJSON([]byte(in))
+		return string(b), err
+	}
+	cases := trial.Cases[string, string]{
+		"line comments": {
+			Input:    "{\n  \"a\": 1, // one\n  // two\n  \"b\": \"//x\"\n}",
+			Expected: "{\n  \"a\": 1, \n  \n  \"b\": \"//x\"\n}",
+		},
+		"block comments": {
+			Input:    "{/* a\nb */\"a\": \"/*\\\"*/\"}",
+			Expected: "{\n\"a\": \"/*\\\"*/\"}",
+		},
+		"trailing commas": {
+			Input:    "{\"a\": [1, 2,], \"b\": {\"c\": 3, /* c */ },}",
+			Expected: "{\"a\": [1, 2 ], \"b\": {\"c\": 3   } }",
+		},
+		"unterminated string": {
+			Input:     "{\"a\": \"b}",
+			ShouldErr: true,
+		},
+		"unterminated comment": {
+			Input:     "{/* a }",
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestMarkJSONComments(t *testing.T) {
+	type item struct {
+		Name string `comment:"not commented in lists"`
+	}
+	type Embed struct {
+		Level int `comment:"promoted"`
+	}
+	type config struct {
+		Host  string `json:"host" comment:"host name"`
+		Items []item `json:"items"`
+		Skip  string `json:"-" comment:"skipped"`
+		Embed
+	}
+	c := &config{Items: []item{{Name: "a"}}}
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, c, "jsonc"); err != nil {
+		t.Fatal(err)
+	}
+	exp := "{\n  // host name\n  \"host\": \"\",\n  \"items\": [\n    {\n      \"Name\": \"a\"\n    }\n  ],\n  // promoted\n  \"Level\": 0\n}\n"
+	if eq, diff := trial.Equal(buf.String(), exp); !eq {
+		t.Error(diff)
+	}
+
+	// the template is valid jsonc
+	out := &config{}
+	if err := LoadBytes(buf.Bytes(), "jsonc", "", out); err != nil {
+		t.Fatal(err)
+	}
+	if eq, diff := trial.Equal(out, c); !eq {
+		t.Error(diff)
+	}
+}
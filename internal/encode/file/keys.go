@@ -0,0 +1,172 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+	"github.com/hydronica/go-config/internal/encode/env"
+	"github.com/hydronica/go-config/internal/encode/hcl"
+	"github.com/hydronica/go-config/internal/encode/ini"
+	"github.com/hydronica/go-config/internal/encode/properties"
+	"github.com/hydronica/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Keys returns the lowercase dotted keys with a value in the config file f
+// (the variable names of a .env file). The keys of the profile section
+// (see ProfilesKey) or yaml profile document are also listed without the
+// section prefix. i is the config the file is loaded into.
+func (l Loader) Keys(f, profile string, i interface{}) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	format := l.format(f)
+	if format == "env" {
+		vars, err := env.ReadEnvFile(f)
+		for k, v := range vars {
+			if v != "" {
+				keys[strings.ToLower(k)] = true
+			}
+		}
+		return keys, err
+	}
+	b, err := ioutil.ReadFile(f)
+	if err != nil {
+		return keys, err
+	}
+	switch format {
+	case "toml":
+		var m map[string]interface{}
+		if _, err := toml.Decode(string(b), &m); err != nil {
+			return keys, err
+		}
+		flatten("", m, keys)
+	case "json", "jsonc":
+		if b, err = l.standardJSON(b, strings.Trim(filepath.Ext(f), ".")); err != nil {
+			return keys, err
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return keys, err
+		}
+		flatten("", m, keys)
+	case "yaml", "yml":
+		docs, err := yamlDocs(b, profile, i)
+		if err != nil {
+			return keys, err
+		}
+		for _, doc := range docs {
+			flatten("", doc, keys)
+		}
+	case "ini", "properties", "hcl":
+		var values map[string]string
+		var lists map[string][]string
+		switch format {
+		case "ini":
+			values, err = ini.Parse(b)
+		case "properties":
+			values, err = properties.Parse(b)
+		case "hcl":
+			values, lists, err = hcl.Parse(b)
+		}
+		if err != nil {
+			return keys, err
+		}
+		for k := range values {
+			keys[strings.ToLower(k)] = true
+		}
+		for k := range lists {
+			keys[strings.ToLower(k)] = true
+		}
+	default:
+		return keys, fmt.Errorf("unknown format %s", format)
+	}
+	if profile != "" {
+		prefix := strings.ToLower(ProfilesKey + "." + profile + ".")
+		for k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				keys[k[len(prefix):]] = true
+			}
+		}
+	}
+	return keys, nil
+}
+
+// HasField reports if the keys of the config file f (see Keys) have the field.
+func (l Loader) HasField(keys map[string]bool, f string, field encode.Field) bool {
+	format := l.format(f)
+	if format != "env" {
+		k := refKey(field, format)
+		return k != "" && keys[strings.ToLower(k)]
+	}
+	d := l.Env
+	if d == nil {
+		d = env.New()
+	}
+	for _, n := range d.LookupNames(field.Env, field.EnvAliases) {
+		if keys[strings.ToLower(n)] {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlDocs returns the documents of the yaml file b that are loaded for the
+// profile: the base documents and the profile document (see decodeYaml).
+func yamlDocs(b []byte, profile string, i interface{}) ([]map[interface{}]interface{}, error) {
+	var docs []map[interface{}]interface{}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var doc map[interface{}]interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	if len(docs) < 2 || hasKey(i, ProfileKey) {
+		return docs, nil
+	}
+	var loaded []map[interface{}]interface{}
+	for _, doc := range docs {
+		if name, ok := doc[ProfileKey]; !ok || (profile != "" && name == profile) {
+			loaded = append(loaded, doc)
+		}
+	}
+	return loaded, nil
+}
+
+// flatten adds the dotted keys of the leaf values of the decoded document v.
+func flatten(prefix string, v interface{}, keys map[string]bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		for k, val := range m {
+			flatten(join(prefix, k), val, keys)
+		}
+	case map[interface{}]interface{}:
+		for k, val := range m {
+			flatten(join(prefix, fmt.Sprint(k)), val, keys)
+		}
+	default:
+		if v != nil && prefix != "" {
+			keys[strings.ToLower(prefix)] = true
+		}
+	}
+}
+
+// join the dotted key prefix and name
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,42 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestLoader_Keys(t *testing.T) {
+	type input struct {
+		file    string
+		profile string
+	}
+	fn := func(in input) (map[string]bool, error) {
+		return Loader{}.Keys(filePath+in.file, in.profile, &SimpleStruct{})
+	}
+	cases := trial.Cases[input, map[string]bool]{
+		"toml": {
+			Input:    input{file: "test.toml"},
+			Expected: map[string]bool{"name": true, "value": true, "enable": true, "time": true, "float32": true, "dura": true},
+		},
+		"nested": {
+			Input:    input{file: "secrets/nested_password.toml"},
+			Expected: map[string]bool{"db.password": true},
+		},
+		"yaml profile documents": {
+			Input: input{file: "profile.yaml", profile: "stage"},
+			Expected: map[string]bool{"name": true, "value": true, "enable": true,
+				"profiles.stage.name": true, "profiles.stage.value": true},
+		},
+		"yaml other profile document": {
+			Input: input{file: "profile.yaml", profile: "prod"},
+			Expected: map[string]bool{"name": true, "value": true, "enable": true, "profile": true,
+				"profiles.stage.name": true, "profiles.stage.value": true},
+		},
+		"missing": {
+			Input:     input{file: "missing.toml"},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
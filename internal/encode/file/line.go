@@ -0,0 +1,98 @@
+package file
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// KeyLine returns the line number (starting at 1) where the dotted key is
+// defined in the config file f or 0 if it cannot be found.
+//
+// This is a best effort lookup that works across the supported formats.
+// Each key segment is searched for in order, starting from the line of the
+// previous segment so that "db.host" will find the host key that follows
+// the db table/object. Keys are matched case-insensitively.
+func KeyLine(f, key string) int {
+	return KeyLineProfile(f, "", key)
+}
+
+// KeyLineProfile is the same as KeyLine except the key of the profile section
+// (see ProfilesKey) or yaml profile document is returned when it is defined.
+func KeyLineProfile(f, profile, key string) int {
+	b, err := ioutil.ReadFile(f)
+	if err != nil || key == "" {
+		return 0
+	}
+	lines := strings.Split(string(b), "\n")
+	if profile != "" {
+		if n := keyLine(lines, 0, ProfilesKey+"."+profile+"."+key); n > 0 {
+			return n
+		}
+		doc := regexp.MustCompile(`^` + ProfileKey + `:\s*["']?` + regexp.QuoteMeta(profile) + `["']?\s*$`)
+		for i, l := range lines {
+			if !doc.MatchString(l) {
+				continue
+			}
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(lines[end], "---") {
+				end++
+			}
+			if n := keyLine(lines[:end], i, key); n > 0 {
+				return n
+			}
+		}
+	}
+	return keyLine(lines, 0, key)
+}
+
+// keyLine searches for the segments of the key from the start line. The
+// segments after the first are only searched for within the table or block
+// of the previous segment, so each candidate line of a segment is tried until
+// the rest of the key is found (ie "profiles.stage.value" is not found in the
+// prod profile that comes first).
+func keyLine(lines []string, start int, key string) int {
+	return segmentLine(lines, start, strings.Split(key, "."), false)
+}
+
+func segmentLine(lines []string, start int, segs []string, scoped bool) int {
+	// key followed by an assignment (=, :), the end of a table header, a block,
+	// a dotted key (ie profiles.prod.name) or a block label (ie profiles "prod")
+	re, err := regexp.Compile(`(?i)^\s*(export\s+)?\[*\s*(["']?[\w\-.]*(\.|["']?\s+))?["']?` + regexp.QuoteMeta(segs[0]) + `["']?\s*([:=.\]{]|["'])`)
+	if err != nil {
+		return 0
+	}
+	for i := start; i < len(lines); i++ {
+		if scoped && i > start && endOfBlock(lines[start], lines[i]) {
+			break
+		}
+		if !re.MatchString(lines[i]) {
+			continue
+		}
+		if len(segs) == 1 {
+			return i + 1
+		}
+		if n := segmentLine(lines, i, segs[1:], true); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// endOfBlock reports if the line ends the table or block started by parent.
+// A table header (ie [db]) ends at the next header and other blocks end at
+// the next line that is not indented more than the parent.
+func endOfBlock(parent, line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, ";") {
+		return false
+	}
+	if strings.HasPrefix(strings.TrimSpace(parent), "[") {
+		return strings.HasPrefix(trimmed, "[")
+	}
+	return indentation(line) <= indentation(parent)
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
@@ -0,0 +1,52 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestKeyLine(t *testing.T) {
+	type input struct {
+		file string
+		key  string
+	}
+	fn := func(in input) (int, error) {
+		return KeyLine(filePath+in.file, in.key), nil
+	}
+	cases := trial.Cases[input, int]{
+		"toml":         {Input: input{file: "test.toml", key: "enable"}, Expected: 3},
+		"yaml":         {Input: input{file: "test.yaml", key: "dura"}, Expected: 4},
+		"json":         {Input: input{file: "test.json", key: "time"}, Expected: 5},
+		"env":          {Input: input{file: ".env", key: "DURA"}, Expected: 6},
+		"hcl block":    {Input: input{file: "nested.hcl", key: "db.host"}, Expected: 4},
+		"case":         {Input: input{file: "test.toml", key: "Name"}, Expected: 1},
+		"missing key":  {Input: input{file: "test.toml", key: "other"}, Expected: 0},
+		"dotted key":   {Input: input{file: "profile.properties", key: "profiles.stage.value"}, Expected: 6},
+		"missing file": {Input: input{file: "missing.toml", key: "name"}, Expected: 0},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestKeyLineProfile(t *testing.T) {
+	type input struct {
+		file    string
+		profile string
+		key     string
+	}
+	fn := func(in input) (int, error) {
+		return KeyLineProfile(filePath+in.file, in.profile, in.key), nil
+	}
+	cases := trial.Cases[input, int]{
+		"toml":            {Input: input{file: "profile.toml", profile: "stage", key: "name"}, Expected: 8},
+		"toml base":       {Input: input{file: "profile.toml", profile: "prod", key: "value"}, Expected: 2},
+		"no profile":      {Input: input{file: "profile.toml", key: "name"}, Expected: 1},
+		"hcl":             {Input: input{file: "profile.hcl", profile: "stage", key: "value"}, Expected: 10},
+		"ini":             {Input: input{file: "profile.ini", profile: "prod", key: "name"}, Expected: 5},
+		"properties":      {Input: input{file: "profile.properties", profile: "stage", key: "name"}, Expected: 5},
+		"yaml section":    {Input: input{file: "profile.yaml", profile: "stage", key: "name"}, Expected: 5},
+		"yaml document":   {Input: input{file: "profile.yaml", profile: "prod", key: "name"}, Expected: 9},
+		"unknown profile": {Input: input{file: "profile.yaml", profile: "dev", key: "name"}, Expected: 1},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
@@ -0,0 +1,179 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+	"github.com/hydronica/go-config/internal/encode/hcl"
+	"github.com/hydronica/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// ProfilesKey is the reserved config file key for named profile sections.
+// The values of the selected profile are merged on top of the base values.
+//
+//	# toml
+//	host = "localhost"
+//	[profiles.prod]
+//	host = "prod.example.com"
+//
+// Multi-document yaml files may instead declare a profile per document with
+// the ProfileKey. Documents without a profile are the base values. The ProfileKey
+// is a regular key in single document files or when the config has a field
+// with the same key.
+//
+//	host: localhost
+//	---
+//	profile: prod
+//	host: prod.example.com
+const ProfilesKey = "profiles"
+
+// profileKeys returns the keys of a flat format (ie ini, properties or hcl) under the
+// profile section (ie [profiles.prod] or profiles.prod.host) without the section prefix.
+// found reports if the profile section has any keys.
+func profileKeys[V any](values map[string]V, profile string) (keys map[string]V, found bool) {
+	keys = make(map[string]V)
+	if profile == "" {
+		return keys, false
+	}
+	prefix := strings.ToLower(ProfilesKey + "." + profile + ".")
+	for k, v := range values {
+		if strings.HasPrefix(strings.ToLower(k), prefix) {
+			keys[k[len(prefix):]] = v
+		}
+	}
+	return keys, len(keys) > 0
+}
+
+// ProfileKey is the reserved key naming the profile of a yaml document.
+const ProfileKey = "profile"
+
+// decodeTomlProfile decodes the profile section of the toml document b into i.
+// found reports if the document has the profile section.
+func decodeTomlProfile(b []byte, profile string, i interface{}) (found bool, err error) {
+	if profile == "" {
+		return false, nil
+	}
+	var p struct {
+		Profiles map[string]toml.Primitive `toml:"profiles"`
+	}
+	md, err := toml.Decode(string(b), &p)
+	if err != nil {
+		return false, err
+	}
+	prim, ok := p.Profiles[profile]
+	if !ok {
+		return false, nil
+	}
+	return true, md.PrimitiveDecode(prim, i)
+}
+
+// decodeJsonProfile decodes the profile section of the json document b into i.
+// found reports if the document has the profile section.
+func decodeJsonProfile(b []byte, profile string, i interface{}) (found bool, err error) {
+	if profile == "" {
+		return false, nil
+	}
+	var p struct {
+		Profiles map[string]json.RawMessage `json:"profiles"`
+	}
+	if err := json.Unmarshal(b, &p); err != nil {
+		return false, err
+	}
+	raw, ok := p.Profiles[profile]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(raw, i)
+}
+
+// decodeYaml decodes every base document in b followed by the profile
+// sections and documents that match the profile. Documents are only
+// profile documents in multi-document files (see ProfilesKey).
+// found reports if b has the profile.
+func decodeYaml(b []byte, profile string, i interface{}) (found bool, err error) {
+	var docs []map[interface{}]interface{}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var doc map[interface{}]interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return false, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+
+	profileDocs := len(docs) > 1 && !hasKey(i, ProfileKey)
+	var base, overlays [][]byte
+	for _, doc := range docs {
+		if name, ok := doc[ProfileKey]; ok && profileDocs {
+			if profile != "" && name == profile {
+				overlays = append(overlays, mustYaml(doc))
+				found = true
+			}
+			continue
+		}
+		base = append(base, mustYaml(doc))
+		if profiles, ok := doc[ProfilesKey].(map[interface{}]interface{}); ok && profile != "" {
+			if p, ok := profiles[profile]; ok {
+				overlays = append(overlays, mustYaml(p))
+				found = true
+			}
+		}
+	}
+
+	for _, doc := range append(base, overlays...) {
+		if err := yaml.Unmarshal(doc, i); err != nil {
+			return false, err
+		}
+	}
+	return found, nil
+}
+
+// hasKey reports if the config i has a field with the file key
+// (or a nested struct named key) and so the key is not reserved.
+func hasKey(i interface{}, key string) bool {
+	for _, f := range encode.Fields(i, ".") {
+		k := strings.ToLower(f.Key)
+		if k == key || strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// mustYaml re-encodes a decoded yaml value. Values decoded
+// by yaml can always be encoded.
+func mustYaml(v interface{}) []byte {
+	b, _ := yaml.Marshal(v)
+	return b
+}
+
+// decodeHCL decodes the hcl document b into i followed by the profile block
+// (ie profiles "prod" { ... }). found reports if the document has the profile.
+func decodeHCL(b []byte, profile string, i interface{}) (found bool, err error) {
+	values, lists, err := hcl.Parse(b)
+	if err != nil {
+		return false, err
+	}
+	if err := encode.UnmarshalKeys(values, i); err != nil {
+		return false, err
+	}
+	if err := encode.UnmarshalLists(lists, i); err != nil {
+		return false, err
+	}
+	keys, foundKeys := profileKeys(values, profile)
+	listKeys, foundLists := profileKeys(lists, profile)
+	if err := encode.UnmarshalKeys(keys, i); err != nil {
+		return false, err
+	}
+	return foundKeys || foundLists, encode.UnmarshalLists(listKeys, i)
+}
@@ -11,47 +11,67 @@ import (
 	"strings"
 	"time"
 
-	"github.com/iancoleman/strcase"
 	"github.com/jbsmith7741/go-tools/appenderr"
 
 	"github.com/hydronica/go-config/internal/encode"
 )
 
+// Separators that may be used to join a nested struct's prefix to its child flag names.
+const (
+	DotSeparator  = "."
+	DashSeparator = "-"
+)
+
 type Flags struct {
 	*flag.FlagSet
 	defaults  map[string]string
 	remaining []string
+	sep       string // joins nested struct prefixes to child flag names
 }
 
 // New creates a custom flagset based on the struct i.
+// Nested struct fields are registered with their parent name
+// as a prefix joined by the DotSeparator (ie -db.host).
 func New(i interface{}) (*Flags, error) {
+	return NewWithSeparator(i, DotSeparator)
+}
+
+// NewWithSeparator is the same as New except nested struct prefixes
+// are joined to the child flag name with sep. sep must be either
+// DotSeparator or DashSeparator.
+func NewWithSeparator(i interface{}, sep string) (*Flags, error) {
+	if sep != DotSeparator && sep != DashSeparator {
+		return nil, fmt.Errorf("invalid flag separator %q, must be %q or %q", sep, DotSeparator, DashSeparator)
+	}
 	flg := &Flags{
 		defaults: make(map[string]string),
+		sep:      sep,
 	}
-	flagSet := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flg.FlagSet = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	if i == nil {
-		flg.FlagSet = flagSet
 		return flg, nil
 	}
 	if !isValidConfig(i) {
 		return nil, errors.New("invalid config, must be pointer to a struct")
 	}
-	vStruct := reflect.ValueOf(i).Elem()
-	for i := 0; i < vStruct.NumField(); i++ {
-		field := vStruct.Field(i)
-		dField := vStruct.Type().Field(i)
-		tag := dField.Tag.Get(encode.FlagTag)
-		name := strcase.ToKebab(dField.Name)
-		desc := dField.Tag.Get(encode.DescTag)
-		confTag := dField.Tag.Get(encode.ConfigTag)
-		if tag == "" {
-			tag = name
-		}
+	flg.register(i)
+	return flg, nil
+}
 
-		// skip private variables and disabled flags
-		if tag == "-" || confTag == "ignore" || !field.CanSet() {
+// register sets up a flag for every supported field of the struct pointer i.
+// Nested struct fields are named with their parent's flag name as the
+// prefix (see encode.Fields). Fields that may not be set by a flag
+// (see encode.Sources) are not registered.
+func (f *Flags) register(i interface{}) {
+	for _, fld := range encode.Fields(i, f.sep) {
+		if fld.Flag == "" || !encode.SourceAllowed(fld.Sources, "flag") {
 			continue
 		}
+		field, dField, tag := fld.Value, fld.Struct, fld.Flag
+		desc := dField.Tag.Get(encode.DescTag)
+		if dField.Tag.Get(encode.ReqTag) == "true" {
+			desc = strings.TrimSpace(desc + " (required)")
+		}
 
 		if isAlias(field) {
 			/*if field.Type().String() == "time.Duration" {
@@ -61,14 +81,14 @@ func New(i interface{}) (*Flags, error) {
 			}*/
 			if implementsStringer(field) {
 				s := field.Interface().(fmt.Stringer).String()
-				flagSet.String(tag, s, desc)
-				flg.defaults[tag] = s
+				f.String(tag, s, desc)
+				f.defaults[tag] = s
 				continue
 			}
 			if implementsMarshaler(field) {
 				b, _ := field.Interface().(encoding.TextMarshaler).MarshalText()
-				flagSet.String(tag, string(b), desc)
-				flg.defaults[tag] = string(b)
+				f.String(tag, string(b), desc)
+				f.defaults[tag] = string(b)
 				continue
 			}
 		}
@@ -79,30 +99,30 @@ func New(i interface{}) (*Flags, error) {
 			continue
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
 			i := int(field.Int())
-			flagSet.Int(tag, i, desc)
-			flg.defaults[tag] = strconv.Itoa(i)
+			f.Int(tag, i, desc)
+			f.defaults[tag] = strconv.Itoa(i)
 		case reflect.Int64:
-			flagSet.Int64(tag, field.Int(), desc)
-			flg.defaults[tag] = strconv.FormatInt(field.Int(), 10)
+			f.Int64(tag, field.Int(), desc)
+			f.defaults[tag] = strconv.FormatInt(field.Int(), 10)
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
-			flagSet.Uint(tag, uint(field.Uint()), desc)
-			flg.defaults[tag] = strconv.FormatUint(field.Uint(), 10)
+			f.Uint(tag, uint(field.Uint()), desc)
+			f.defaults[tag] = strconv.FormatUint(field.Uint(), 10)
 		case reflect.Uint64:
-			flagSet.Uint64(tag, field.Uint(), desc)
-			flg.defaults[tag] = strconv.FormatUint(field.Uint(), 10)
+			f.Uint64(tag, field.Uint(), desc)
+			f.defaults[tag] = strconv.FormatUint(field.Uint(), 10)
 		case reflect.String:
-			flagSet.String(tag, field.String(), desc)
-			flg.defaults[tag] = field.String()
+			f.String(tag, field.String(), desc)
+			f.defaults[tag] = field.String()
 		case reflect.Bool:
-			flagSet.Bool(tag, field.Bool(), desc)
+			f.Bool(tag, field.Bool(), desc)
 			if field.Bool() {
-				flg.defaults[tag] = "true"
+				f.defaults[tag] = "true"
 			} else {
-				flg.defaults[tag] = "false"
+				f.defaults[tag] = "false"
 			}
 		case reflect.Float32, reflect.Float64:
-			flagSet.Float64(tag, field.Float(), desc)
-			flg.defaults[tag] = strconv.FormatFloat(field.Float(), 'f', -1, 64)
+			f.Float64(tag, field.Float(), desc)
+			f.defaults[tag] = strconv.FormatFloat(field.Float(), 'f', -1, 64)
 		case reflect.Ptr:
 			// if nil create a new instance so we can setup the flag
 			if field.IsNil() {
@@ -116,21 +136,19 @@ func New(i interface{}) (*Flags, error) {
 				timeFmt := dField.Tag.Get(encode.FormatTag)
 				timeFmt = getTimeFormat(timeFmt)
 				t := field.Interface().(time.Time)
-				flagSet.String(tag, t.Format(timeFmt), desc)
-				flg.defaults[tag] = t.Format(timeFmt)
+				f.String(tag, t.Format(timeFmt), desc)
+				f.defaults[tag] = t.Format(timeFmt)
 				continue
 			}
 
 			// support a struct if they implement a marshaler
 			if implementsMarshaler(field) {
 				b, _ := field.Interface().(encoding.TextMarshaler).MarshalText()
-				flagSet.String(tag, string(b), desc)
-				flg.defaults[tag] = string(b)
+				f.String(tag, string(b), desc)
+				f.defaults[tag] = string(b)
 			}
 		}
 	}
-	flg.FlagSet = flagSet
-	return flg, nil
 }
 
 // Parse the internal flags and the user defined flags.
@@ -207,34 +225,39 @@ func isBoolFlag(fs *flag.FlagSet, name string) bool {
 	return ok && bf.IsBoolFlag()
 }
 
+// IsSet reports if the flag was on the command line.
+func (f Flags) IsSet(name string) bool {
+	set := false
+	f.FlagSet.Visit(func(flg *flag.Flag) {
+		if flg.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 // Unmarshal the given struct from the flagSet
 func (f Flags) Unmarshal(c interface{}) error {
 	if !isValidConfig(c) {
 		return errors.New("invalid config")
 	}
 
-	vStruct := reflect.ValueOf(c).Elem()
+	// only allocate a nil struct pointer when one of its child flags is set
 	errs := appenderr.New()
-	for i := 0; i < vStruct.NumField(); i++ {
-		field := vStruct.Field(i)
-		dField := vStruct.Type().Field(i)
-		tag := dField.Tag.Get(encode.FlagTag)
-		name := strcase.ToKebab(dField.Name)
-		//confTag := dField.Tag.Get(configTag)
-		if tag != "" {
-			name = tag
+	for _, fld := range encode.Fields(c, f.sep) {
+		if fld.Flag == "" {
+			continue
 		}
-
-		flg := f.FlagSet.Lookup(name)
+		flg := f.FlagSet.Lookup(fld.Flag)
 		if flg == nil {
 			// ignore all types without flags
 			continue
 		}
 		// skip flags set to default
-		if f.defaults[name] == flg.Value.String() {
+		if f.defaults[fld.Flag] == flg.Value.String() {
 			continue
 		}
-		errs.Add(encode.SetField(field, flg.Value.String(), dField))
+		errs.Add(encode.SetField(fld.Alloc(), flg.Value.String(), fld.Struct))
 	}
 	return errs.ErrOrNil()
 }
@@ -118,6 +118,16 @@ func TestNew(t *testing.T) {
 				"Count": {Def: "10", Usage: "number of people in a room"},
 			},
 		},
+		"required": {
+			Input: &struct {
+				Host string `req:"true" comment:"db host"`
+				Port int    `req:"true"`
+			}{Port: 5432},
+			Expected: map[string]*tFlag{
+				"host": {Def: "", Usage: "db host (required)"},
+				"port": {Def: "5432", Usage: "(required)"},
+			},
+		},
 		"time": {
 			Input: &struct {
 				Time     time.Time
@@ -194,6 +204,48 @@ func TestNew(t *testing.T) {
 				"my-struct": {Def: "c"},
 			},
 		},
+		"nested struct": {
+			Input: &struct {
+				Host string
+				DB   DBCreds `flag:"db"`
+				Auth *DBCreds
+			}{
+				Host: "localhost",
+				DB:   DBCreds{Username: "admin"},
+			},
+			Expected: map[string]*tFlag{
+				"host":    {Def: "localhost"},
+				"db.un":   {Def: "admin", Usage: "db username"},
+				"db.pw":   {Def: ""},
+				"auth.un": {Def: "", Usage: "db username"},
+				"auth.pw": {Def: ""},
+			},
+		},
+		"embedded struct": {
+			Input: &struct {
+				DBCreds
+				Embed DBCreds `flag:"e"`
+			}{},
+			Expected: map[string]*tFlag{
+				"un":   {Def: "", Usage: "db username"},
+				"pw":   {Def: ""},
+				"e.un": {Def: "", Usage: "db username"},
+				"e.pw": {Def: ""},
+			},
+		},
+		"source restricted": {
+			Input: &struct {
+				Host     string
+				Password string   `source:"env,file"`
+				Token    string   `source:"flag"`
+				DB       DBCreds  `flag:"db" source:"env"`
+				Auth     *DBCreds `source:"file"`
+			}{},
+			Expected: map[string]*tFlag{
+				"host":  {Def: ""},
+				"token": {Def: ""},
+			},
+		},
 	}
 	trial.New(fn, cases).SubTest(t)
 }
@@ -238,6 +290,12 @@ func TestUnmarshal(t *testing.T) {
 		PStruct *mStruct `flag:"pstruct"`
 	}
 
+	type nConfig struct {
+		Name   string
+		DB     DBCreds `flag:"db"`
+		Backup *DBCreds
+	}
+
 	type input struct {
 		config interface{}
 		args   []string
@@ -340,6 +398,34 @@ func TestUnmarshal(t *testing.T) {
 				Dura:    10 * time.Second,
 			},
 		},
+		"nested struct": {
+			Input: input{
+				config: &nConfig{DB: DBCreds{Username: "default"}},
+				args:   []string{"-db.pw=secret", "-name=app"},
+			},
+			Expected: &nConfig{Name: "app", DB: DBCreds{Username: "default", Password: "secret"}},
+		},
+		"nil struct pointer not set": {
+			Input: input{
+				config: &nConfig{},
+				args:   []string{"-db.un=admin"},
+			},
+			Expected: &nConfig{DB: DBCreds{Username: "admin"}},
+		},
+		"nil struct pointer set": {
+			Input: input{
+				config: &nConfig{},
+				args:   []string{"-backup.un=admin"},
+			},
+			Expected: &nConfig{Backup: &DBCreds{Username: "admin"}},
+		},
+		"existing struct pointer": {
+			Input: input{
+				config: &nConfig{Backup: &DBCreds{Username: "admin"}},
+				args:   []string{"-backup.pw=secret"},
+			},
+			Expected: &nConfig{Backup: &DBCreds{Username: "admin", Password: "secret"}},
+		},
 		"private values": {
 			Input: input{
 				config: &struct {
@@ -432,6 +518,48 @@ func TestFlags_Args(t *testing.T) {
 	trial.New(fn, cases).SubTest(t)
 }
 
+func TestNewWithSeparator(t *testing.T) {
+	type input struct {
+		sep  string
+		args []string
+	}
+	type config struct {
+		DB DBCreds
+	}
+	fn := func(in input) (*config, error) {
+		c := &config{}
+		os.Args = append([]string{"go-config"}, in.args...)
+		f, err := NewWithSeparator(c, in.sep)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.Parse(); err != nil {
+			return nil, err
+		}
+		return c, f.Unmarshal(c)
+	}
+	cases := trial.Cases[input, *config]{
+		"dot": {
+			Input:    input{sep: ".", args: []string{"-db.un=admin"}},
+			Expected: &config{DB: DBCreds{Username: "admin"}},
+		},
+		"dash": {
+			Input:    input{sep: "-", args: []string{"-db-un=admin"}},
+			Expected: &config{DB: DBCreds{Username: "admin"}},
+		},
+		"invalid": {
+			Input:       input{sep: "_"},
+			ExpectedErr: errors.New("invalid flag separator"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+type DBCreds struct {
+	Username string `flag:"un" comment:"db username"`
+	Password string `flag:"pw"`
+}
+
 type mAlias int
 
 var nums = []string{"zero", "one", "two", "three", "four", "five"}
@@ -0,0 +1,344 @@
+package hcl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// Unmarshal decodes the HCL document b into the struct pointer v.
+// Blocks map to nested structs and their labels are nested keys
+// (ie profiles "prod" { ... } is profiles.prod). Lists are set to
+// slices and keys are matched case-insensitively (see encode.UnmarshalKeys
+// and encode.UnmarshalLists).
+func Unmarshal(b []byte, v interface{}) error {
+	values, lists, err := Parse(b)
+	if err != nil {
+		return err
+	}
+	if err := encode.UnmarshalKeys(values, v); err != nil {
+		return err
+	}
+	return encode.UnmarshalLists(lists, v)
+}
+
+// Parse reads the attributes of the HCL document b into maps of dotted keys
+// (ie db.host) where each key is prefixed by its block type and labels.
+// Attributes may be strings, heredocs, numbers, bools, null, lists or objects.
+// Lists are returned separately from the other values with their elements
+// kept apart and null values are left out. '#', '//' and '/* */' are comments.
+func Parse(b []byte) (values map[string]string, lists map[string][]string, err error) {
+	p := &parser{src: string(b), line: 1, values: make(map[string]string), lists: make(map[string][]string)}
+	if err := p.body("", false); err != nil {
+		return nil, nil, fmt.Errorf("hcl: line %d: %w", p.line, err)
+	}
+	return p.values, p.lists, nil
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+
+	values map[string]string
+	lists  map[string][]string
+}
+
+// body parses attributes and blocks until the end of the document
+// or the closing brace of the block when inBlock is set.
+func (p *parser) body(prefix string, inBlock bool) error {
+	for {
+		p.skip(true)
+		if p.pos >= len(p.src) {
+			if inBlock {
+				return fmt.Errorf("missing closing brace")
+			}
+			return nil
+		}
+		if p.src[p.pos] == '}' && inBlock {
+			p.pos++
+			return nil
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		key := join(prefix, name)
+		p.skip(false)
+		if p.peek() == '=' || p.peek() == ':' {
+			p.pos++
+			if err := p.value(key); err != nil {
+				return err
+			}
+			// object attributes may be separated by commas
+			if p.skip(false); p.peek() == ',' {
+				p.pos++
+			}
+			continue
+		}
+
+		// block with optional labels (ie profiles "prod" {)
+		for p.peek() != '{' {
+			var label string
+			if p.peek() == '"' {
+				label, err = p.str()
+			} else {
+				label, err = p.ident()
+			}
+			if err != nil {
+				return fmt.Errorf("expected '=' or '{' after %s", name)
+			}
+			key = join(key, label)
+			p.skip(false)
+		}
+		p.pos++
+		if err := p.body(key, true); err != nil {
+			return err
+		}
+	}
+}
+
+// value parses the expression of the attribute key into the values or lists.
+func (p *parser) value(key string) error {
+	p.skip(false)
+	switch c := p.peek(); {
+	case c == '{':
+		p.pos++
+		return p.body(key, true)
+	case c == '[':
+		p.pos++
+		list := []string{}
+		for {
+			p.skip(true)
+			if p.peek() == ']' {
+				p.pos++
+				delete(p.values, key)
+				p.lists[key] = list
+				return nil
+			}
+			s, null, err := p.scalar()
+			if err != nil {
+				return err
+			}
+			if !null {
+				list = append(list, s)
+			}
+			p.skip(true)
+			switch p.peek() {
+			case ',':
+				p.pos++
+			case ']':
+			default:
+				return fmt.Errorf("expected ',' or ']' in list %s", key)
+			}
+		}
+	default:
+		s, null, err := p.scalar()
+		if err != nil {
+			return err
+		}
+		if !null {
+			delete(p.lists, key)
+			p.values[key] = s
+		}
+		return nil
+	}
+}
+
+// scalar parses a string, heredoc, number, bool or null value.
+func (p *parser) scalar() (s string, null bool, err error) {
+	switch {
+	case p.peek() == '"':
+		s, err = p.str()
+		return s, false, err
+	case strings.HasPrefix(p.src[p.pos:], "<<"):
+		s, err = p.heredoc()
+		return s, false, err
+	}
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n,]}#/", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	s = p.src[start:p.pos]
+	switch s {
+	case "":
+		return "", false, fmt.Errorf("expected a value")
+	case "null":
+		return "", true, nil
+	case "true", "false":
+		return s, false, nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return "", false, fmt.Errorf("unsupported value %q", s)
+	}
+	return s, false, nil
+}
+
+// str parses a double quoted string with the \n \r \t \" \\ and \uXXXX escapes.
+func (p *parser) str() (string, error) {
+	var b strings.Builder
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\n':
+			return "", fmt.Errorf("unterminated string")
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.src) {
+				return "", fmt.Errorf("unterminated string")
+			}
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+5 > len(p.src) {
+					return "", fmt.Errorf("malformed \\u escape")
+				}
+				r, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("malformed \\u escape")
+				}
+				b.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// heredoc parses a <<EOF or indented <<-EOF string. The trailing
+// newline is removed and <<- strips the common leading whitespace.
+func (p *parser) heredoc() (string, error) {
+	p.pos += 2
+	indent := p.peek() == '-'
+	if indent {
+		p.pos++
+	}
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		return "", fmt.Errorf("unterminated heredoc")
+	}
+	marker := strings.TrimSpace(p.src[p.pos : p.pos+end])
+	if marker == "" {
+		return "", fmt.Errorf("missing heredoc marker")
+	}
+	p.pos += end + 1
+	p.line++
+
+	var lines []string
+	for p.pos < len(p.src) {
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		line := p.src[p.pos : p.pos+end]
+		p.pos += end
+		if strings.TrimSpace(line) == marker {
+			return dedent(lines, indent), nil
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\r"))
+		if p.pos < len(p.src) {
+			p.pos++
+			p.line++
+		}
+	}
+	return "", fmt.Errorf("unterminated heredoc %s", marker)
+}
+
+// dedent joins the heredoc lines removing the common leading whitespace when indent is set.
+func dedent(lines []string, indent bool) string {
+	if indent {
+		min := -1
+		for _, l := range lines {
+			if strings.TrimSpace(l) == "" {
+				continue
+			}
+			if n := len(l) - len(strings.TrimLeft(l, " \t")); min < 0 || n < min {
+				min = n
+			}
+		}
+		for i, l := range lines {
+			if len(l) >= min && min > 0 {
+				lines[i] = l[min:]
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ident parses an identifier (letters, digits, '_' and '-')
+func (p *parser) ident() (string, error) {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			break
+		}
+		p.pos += size
+	}
+	if start == p.pos {
+		return "", fmt.Errorf("unexpected %q", p.peek())
+	}
+	return p.src[start:p.pos], nil
+}
+
+// skip whitespace and comments. Newlines are only skipped when newlines is set.
+func (p *parser) skip(newlines bool) {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\n':
+			if !newlines {
+				return
+			}
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '#' || strings.HasPrefix(p.src[p.pos:], "//"):
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				p.pos = len(p.src)
+				return
+			}
+			p.line += strings.Count(p.src[p.pos:p.pos+end+2], "\n")
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+// peek returns the current character or 0 at the end of the document
+func (p *parser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
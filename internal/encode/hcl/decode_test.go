@@ -0,0 +1,110 @@
+package hcl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hydronica/trial"
+)
+
+func TestParse(t *testing.T) {
+	type parsed struct {
+		Values map[string]string
+		Lists  map[string][]string
+	}
+	fn := func(in string) (parsed, error) {
+		values, lists, err := Parse([]byte(in))
+		if len(lists) == 0 {
+			lists = nil
+		}
+		return parsed{Values: values, Lists: lists}, err
+	}
+	cases := trial.Cases[string, parsed]{
+		"attributes": {
+			Input:    "name = \"app\"\nport = 8080\nratio = -1.5\nenabled = true\nunset = null\n",
+			Expected: parsed{Values: map[string]string{"name": "app", "port": "8080", "ratio": "-1.5", "enabled": "true"}},
+		},
+		"blocks": {
+			Input:    "db {\n  host = \"localhost\"\n  replica {\n    host = \"replica\"\n  }\n}\nprofiles \"prod\" {\n  name = \"prod\"\n}\n",
+			Expected: parsed{Values: map[string]string{"db.host": "localhost", "db.replica.host": "replica", "profiles.prod.name": "prod"}},
+		},
+		"object": {
+			Input:    "db = { host = \"localhost\", port = 5432 }\n",
+			Expected: parsed{Values: map[string]string{"db.host": "localhost", "db.port": "5432"}},
+		},
+		"lists": {
+			Input: "tags = [\"a\", \"b,c\",\n  \"d\", # comment\n]\nports = [80, 443]\nempty = []\nname = \"app\"\n",
+			Expected: parsed{
+				Values: map[string]string{"name": "app"},
+				Lists:  map[string][]string{"tags": {"a", "b,c", "d"}, "ports": {"80", "443"}, "empty": {}},
+			},
+		},
+		"comments": {
+			Input:    "# comment\n// comment\n/* multi\nline */ name = \"app\" # inline\nurl = \"http://host/#x\" // inline\n",
+			Expected: parsed{Values: map[string]string{"name": "app", "url": "http://host/#x"}},
+		},
+		"escapes": {
+			Input:    `name = "a \"quoted\"\tvalue é\\"`,
+			Expected: parsed{Values: map[string]string{"name": "a \"quoted\"\tvalue é\\"}},
+		},
+		"heredoc": {
+			Input:    "text = <<EOF\nline 1\n  line 2\nEOF\nindented = <<-EOT\n    a\n      b\n    EOT\n",
+			Expected: parsed{Values: map[string]string{"text": "line 1\n  line 2", "indented": "a\n  b"}},
+		},
+		"unterminated string": {
+			Input:     "name = \"app\n",
+			ShouldErr: true,
+		},
+		"missing brace": {
+			Input:     "db {\n host = \"x\"\n",
+			ShouldErr: true,
+		},
+		"unsupported expression": {
+			Input:     "name = var.name\n",
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestUnmarshal(t *testing.T) {
+	type db struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		Name    string
+		Dura    time.Duration
+		Tags    []string
+		Ports   []int
+		DB      db `toml:"database"`
+		Replica *db
+	}
+	fn := func(in string) (*config, error) {
+		c := &config{Name: "default"}
+		err := Unmarshal([]byte(in), c)
+		return c, err
+	}
+	cases := trial.Cases[string, *config]{
+		"blocks and lists": {
+			Input: "dura = \"5s\"\ntags = [\"a\", \"b\"]\nports = [80, 443]\ndatabase {\n  host = \"localhost\"\n  port = 5432\n}\nreplica {\n  host = \"replica\"\n}\n",
+			Expected: &config{
+				Name:    "default",
+				Dura:    5 * time.Second,
+				Tags:    []string{"a", "b"},
+				Ports:   []int{80, 443},
+				DB:      db{Host: "localhost", Port: 5432},
+				Replica: &db{Host: "replica"},
+			},
+		},
+		"list elements with commas": {
+			Input:    "tags = [\"a,b\", \"c\"]\n",
+			Expected: &config{Name: "default", Tags: []string{"a,b", "c"}},
+		},
+		"invalid value": {
+			Input:     "database {\n  port = \"abc\"\n}\n",
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
@@ -0,0 +1,99 @@
+package hcl
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// Marshal encodes the struct pointer v as an HCL document. Nested structs
+// are written as blocks and slices as lists. The comment tag of a field is
+// written as a '#' comment above its attribute and required fields are
+// marked with a trailing '# required' comment.
+func Marshal(v interface{}) ([]byte, error) {
+	if value := reflect.ValueOf(v); value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("'%v' must be a non-nil pointer struct", reflect.TypeOf(v))
+	}
+	buf := &bytes.Buffer{}
+	var open []string // the currently open blocks
+	for _, f := range encode.Fields(v, ".") {
+		if f.Key == "" || f.Value.Kind() == reflect.Map {
+			continue
+		}
+		path := strings.Split(f.Key, ".")
+		name, blocks := path[len(path)-1], path[:len(path)-1]
+
+		// close the blocks that the field is not in and open its blocks
+		shared := 0
+		for shared < len(open) && shared < len(blocks) && open[shared] == blocks[shared] {
+			shared++
+		}
+		for len(open) > shared {
+			open = open[:len(open)-1]
+			fmt.Fprintf(buf, "%s}\n", indent(len(open)))
+		}
+		for _, b := range blocks[shared:] {
+			if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("{\n")) {
+				buf.WriteString("\n")
+			}
+			fmt.Fprintf(buf, "%s%s {\n", indent(len(open)), b)
+			open = append(open, b)
+		}
+
+		pad := indent(len(open))
+		if c := f.Struct.Tag.Get(encode.DescTag); c != "" {
+			fmt.Fprintf(buf, "%s# %s\n", pad, c)
+		}
+		fmt.Fprintf(buf, "%s%s = %s", pad, name, format(f.Value, f.Struct))
+		if f.Struct.Tag.Get(encode.ReqTag) == "true" {
+			buf.WriteString(" # required")
+		}
+		buf.WriteString("\n")
+	}
+	for len(open) > 0 {
+		open = open[:len(open)-1]
+		fmt.Fprintf(buf, "%s}\n", indent(len(open)))
+	}
+	return buf.Bytes(), nil
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// format the value as an HCL expression. Bools and numbers are literals,
+// slices are lists and other values are quoted strings.
+func format(value reflect.Value, sField reflect.StructField) string {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return "null"
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		vals := make([]string, value.Len())
+		for i := range vals {
+			vals[i] = format(value.Index(i), sField)
+		}
+		return "[" + strings.Join(vals, ", ") + "]"
+	case reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return encode.FieldString(value, sField)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() != reflect.TypeOf(time.Duration(0)) {
+			return encode.FieldString(value, sField)
+		}
+	}
+	return quote(encode.FieldString(value, sField))
+}
+
+var quoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+func quote(s string) string {
+	return `"` + quoter.Replace(s) + `"`
+}
@@ -0,0 +1,68 @@
+package hcl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hydronica/trial"
+)
+
+func TestMarshal(t *testing.T) {
+	type replica struct {
+		Host string
+	}
+	type db struct {
+		Host    string `req:"true" comment:"database host"`
+		Port    int
+		Replica replica
+	}
+	type config struct {
+		Name  string `comment:"app name"`
+		Tags  []string
+		Ports []int
+		Dura  time.Duration
+		Ptr   *int
+		DB    db
+		Debug bool
+	}
+	fn := func(in interface{}) (string, error) {
+		b, err := Marshal(in)
+		return string(b), err
+	}
+	cases := trial.Cases[interface{}, string]{
+		"template": {
+			Input: &config{Name: "a \"b\"", Tags: []string{"x", "y"}, Ports: []int{80}, Dura: time.Second, DB: db{Port: 5432}},
+			Expected: "# app name\nname = \"a \\\"b\\\"\"\ntags = [\"x\", \"y\"]\nports = [80]\ndura = \"1s\"\nptr = null\n\n" +
+				"db {\n  # database host\n  host = \"\" # required\n  port = 5432\n\n  replica {\n    host = \"\"\n  }\n}\ndebug = false\n",
+		},
+		"not a pointer": {
+			Input:     config{},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestRoundTrip(t *testing.T) {
+	type db struct {
+		Host string
+		Tags []string
+	}
+	type config struct {
+		Name string
+		Dura time.Duration
+		DB   db
+	}
+	in := &config{Name: "line\nbreak \\ \"q\"", Dura: time.Minute, DB: db{Host: "h", Tags: []string{"a", "b"}}}
+	b, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &config{}
+	if err := Unmarshal(b, out); err != nil {
+		t.Fatal(err)
+	}
+	if eq, diff := trial.Equal(out, in); !eq {
+		t.Error(diff)
+	}
+}
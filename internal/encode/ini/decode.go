@@ -0,0 +1,136 @@
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// Unmarshal decodes the ini document b into the struct pointer v.
+// Each section maps to a nested struct (ie [db] or [db.primary]) and
+// keys are matched case-insensitively to the file keys (see encode.UnmarshalKeys).
+func Unmarshal(b []byte, v interface{}) error {
+	values, err := Parse(b)
+	if err != nil {
+		return err
+	}
+	return encode.UnmarshalKeys(values, v)
+}
+
+// Parse reads the ini document b into a map of dotted keys (ie db.host)
+// where each key is prefixed by its section. Lines starting with ';' or '#'
+// are comments and so is the remainder of an unquoted value after ' ;' or ' #'.
+// Values may be wrapped in double or single quotes to keep leading and
+// trailing spaces or comment characters. Double quoted values support the
+// \" \\ \n and \t escapes. Later keys override earlier keys of the same name.
+func Parse(b []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	section := ""
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("ini: line %d: unterminated section %q", lineNo, line)
+			}
+			section = strings.TrimSpace(line[1:end])
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("ini: line %d: expected key = value", lineNo)
+		}
+		key := strings.TrimSpace(line[:eq])
+		if key == "" {
+			return nil, fmt.Errorf("ini: line %d: missing key", lineNo)
+		}
+		val, err := parseValue(line[eq+1:])
+		if err != nil {
+			return nil, fmt.Errorf("ini: line %d: %w", lineNo, err)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		values[key] = val
+	}
+	return values, sc.Err()
+}
+
+// parseValue returns the unquoted value without its inline comment.
+func parseValue(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case raw == "" || raw[0] == ';' || raw[0] == '#':
+		return "", nil
+	case raw[0] != '"' && raw[0] != '\'':
+		return trimComment(raw), nil
+	}
+	q := raw[0]
+	for i := 1; i < len(raw); i++ {
+		if raw[i] == '\\' && q == '"' {
+			i++
+			continue
+		}
+		if raw[i] != q {
+			continue
+		}
+		rest := strings.TrimSpace(raw[i+1:])
+		if rest != "" && rest[0] != ';' && rest[0] != '#' {
+			// a list of quoted values (ie "a", "b")
+			return trimComment(raw), nil
+		}
+		if q == '\'' {
+			return raw[1:i], nil
+		}
+		return unescape(raw[1:i]), nil
+	}
+	return "", fmt.Errorf("unterminated quoted value")
+}
+
+// trimComment removes a trailing comment starting at a ';' or '#'
+// that is preceded by whitespace and outside of quotes.
+func trimComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case (c == ';' || c == '#') && i > 0 && (s[i-1] == ' ' || s[i-1] == '\t'):
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package ini
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hydronica/trial"
+)
+
+func TestParse(t *testing.T) {
+	cases := trial.Cases[string, map[string]string]{
+		"sections": {
+			Input:    "name = app\n[db]\nhost = localhost\n[db.replica]\nhost = replica\n",
+			Expected: map[string]string{"name": "app", "db.host": "localhost", "db.replica.host": "replica"},
+		},
+		"comments": {
+			Input:    "; comment\n# comment\nname = app ; inline\nurl = http://host/#anchor # inline\nempty = ; inline\n",
+			Expected: map[string]string{"name": "app", "url": "http://host/#anchor", "empty": ""},
+		},
+		"quotes": {
+			Input:    "a = \" padded ; kept \"\nb = 'single \\n'\nc = \"esc \\\"q\\\"\\tt\" ; comment\nd = \"x\", \"y\"\n",
+			Expected: map[string]string{"a": " padded ; kept ", "b": `single \n`, "c": "esc \"q\"\tt", "d": `"x", "y"`},
+		},
+		"unterminated quote": {
+			Input:     "name = \"app\n",
+			ShouldErr: true,
+		},
+		"unterminated section": {
+			Input:     "[db\n",
+			ShouldErr: true,
+		},
+		"missing assignment": {
+			Input:     "name\n",
+			ShouldErr: true,
+		},
+	}
+	fn := func(in string) (map[string]string, error) {
+		return Parse([]byte(in))
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestUnmarshal(t *testing.T) {
+	type db struct {
+		Host string
+		Port int `toml:"db_port"`
+	}
+	type Embed struct {
+		Level int
+	}
+	type config struct {
+		Name    string
+		Dura    time.Duration
+		Tags    []string
+		DB      db
+		Replica *db
+		Unset   *db
+		Embed
+	}
+	fn := func(in string) (*config, error) {
+		c := &config{Name: "default"}
+		err := Unmarshal([]byte(in), c)
+		return c, err
+	}
+	cases := trial.Cases[string, *config]{
+		"values": {
+			Input: "dura = 5s\ntags = a, \"b\", c\nlevel = 2\n[DB]\nHost = localhost\ndb_port = 5432\n[replica]\nhost = replica\n",
+			Expected: &config{
+				Name:    "default",
+				Dura:    5 * time.Second,
+				Tags:    []string{"a", "b", "c"},
+				DB:      db{Host: "localhost", Port: 5432},
+				Replica: &db{Host: "replica"},
+				Embed:   Embed{Level: 2},
+			},
+		},
+		"invalid value": {
+			Input:     "[db]\ndb_port = abc\n",
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
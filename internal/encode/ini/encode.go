@@ -0,0 +1,106 @@
+package ini
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// Marshal encodes the struct pointer v as an ini document. Top level fields
+// are written first followed by a section for each nested struct (ie [db]).
+// The comment tag of a field is written as a ';' comment above its key
+// and required fields are marked with a trailing '; required' comment.
+func Marshal(v interface{}) ([]byte, error) {
+	if value := reflect.ValueOf(v); value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("'%v' must be a non-nil pointer struct", reflect.TypeOf(v))
+	}
+
+	// group the fields by section in the order they are found.
+	// top level keys must come before the first section.
+	sections := []string{""}
+	keys := make(map[string][]encode.Field)
+	for _, f := range encode.Fields(v, ".") {
+		if f.Key == "" || f.Value.Kind() == reflect.Map {
+			continue
+		}
+		section := ""
+		if i := strings.LastIndex(f.Key, "."); i >= 0 {
+			section = f.Key[:i]
+		}
+		if _, ok := keys[section]; !ok && section != "" {
+			sections = append(sections, section)
+		}
+		keys[section] = append(keys[section], f)
+	}
+
+	buf := &bytes.Buffer{}
+	for _, section := range sections {
+		if section != "" {
+			if buf.Len() > 0 {
+				buf.WriteString("\n")
+			}
+			fmt.Fprintf(buf, "[%s]\n", section)
+		}
+		for _, f := range keys[section] {
+			write(buf, f.Key[len(section):], f)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// write the key line of the field with its comments
+func write(buf *bytes.Buffer, key string, f encode.Field) {
+	key = strings.TrimPrefix(key, ".")
+	if c := f.Struct.Tag.Get(encode.DescTag); c != "" {
+		fmt.Fprintf(buf, "; %s\n", c)
+	}
+	fmt.Fprintf(buf, "%s = %s", key, format(f.Value, f.Struct))
+	if f.Struct.Tag.Get(encode.ReqTag) == "true" {
+		buf.WriteString(" ; required")
+	}
+	buf.WriteString("\n")
+}
+
+// format the value as an ini value. Lists are comma separated and
+// the whole value is quoted when needed (see quote).
+func format(value reflect.Value, sField reflect.StructField) string {
+	s := text(value, sField)
+	if s == "" && reflect.Indirect(value).Kind() != reflect.String {
+		return s
+	}
+	return quote(s)
+}
+
+// text of the value without quotes, lists are comma separated.
+func text(value reflect.Value, sField reflect.StructField) string {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value.String()
+	case reflect.Slice, reflect.Array:
+		vals := make([]string, value.Len())
+		for i := range vals {
+			vals[i] = text(value.Index(i), sField)
+		}
+		return strings.Join(vals, ",")
+	}
+	return encode.FieldString(value, sField)
+}
+
+// quote s when it is empty, has leading or trailing spaces
+// or could be read as a quoted value or comment.
+func quote(s string) string {
+	if s != "" && s == strings.TrimSpace(s) && !strings.ContainsAny(s, ";#\"'\n\t") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
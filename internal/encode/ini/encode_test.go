@@ -0,0 +1,67 @@
+package ini
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hydronica/trial"
+)
+
+func TestMarshal(t *testing.T) {
+	type db struct {
+		Host string `req:"true" comment:"database host"`
+		Port int
+	}
+	type config struct {
+		Name  string
+		DB    db
+		Tags  []string
+		Dura  time.Duration
+		Quote string
+		Skip  string `toml:"-"`
+	}
+	fn := func(in interface{}) (string, error) {
+		b, err := Marshal(in)
+		return string(b), err
+	}
+	cases := trial.Cases[interface{}, string]{
+		"template": {
+			Input: &config{Name: "app", DB: db{Port: 5432}, Tags: []string{"a", "b c"}, Dura: time.Minute, Quote: " a ; b"},
+			Expected: "name = app\ntags = a,b c\ndura = 1m0s\nquote = \" a ; b\"\n\n" +
+				"[db]\n; database host\nhost = \"\" ; required\nport = 5432\n",
+		},
+		"not a pointer": {
+			Input:     config{},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestRoundTrip(t *testing.T) {
+	type config struct {
+		Name  string
+		Quote string
+		Tags  []string
+	}
+	fn := func(in *config) (*config, error) {
+		b, err := Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		out := &config{}
+		err = Unmarshal(b, out)
+		return out, err
+	}
+	cases := trial.Cases[*config, *config]{
+		"values": {
+			Input:    &config{Name: "app", Quote: "\t\"x\" # y", Tags: []string{"a", "b"}},
+			Expected: &config{Name: "app", Quote: "\t\"x\" # y", Tags: []string{"a", "b"}},
+		},
+		"quoted list": {
+			Input:    &config{Tags: []string{"a ;b", "c", "d\"e"}},
+			Expected: &config{Tags: []string{"a ;b", "c", "d\"e"}},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
@@ -0,0 +1,112 @@
+package encode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expand replaces shell style variable references in s using lookup.
+//
+//	${VAR}          value of VAR or empty if unset
+//	${VAR:-default} value of VAR or default if unset or empty
+//	${VAR:?message} value of VAR or an error with message if unset or empty
+//	$$              a literal $
+//
+// The default value is also expanded. A '$' that is not followed by '{' or '$' is
+// left as is so values like passwords are not accidentally changed.
+func Expand(s string, lookup func(string) (string, bool)) (string, error) {
+	return expand(s, lookup, false)
+}
+
+// ExpandStrict is the same as Expand except a ${VAR} reference to a VAR
+// that is not found by lookup is an error instead of empty.
+func ExpandStrict(s string, lookup func(string) (string, bool)) (string, error) {
+	return expand(s, lookup, true)
+}
+
+func expand(s string, lookup func(string) (string, bool), strict bool) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte(s[i])
+			continue
+		}
+
+		end := closingBrace(s, i+2)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in %q", s)
+		}
+		v, err := expandRef(s[i+2:end], lookup, strict)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+		i = end
+	}
+	return b.String(), nil
+}
+
+// expandRef expands the contents of a ${...} reference
+func expandRef(ref string, lookup func(string) (string, bool), strict bool) (string, error) {
+	name, op, arg := ref, "", ""
+	if idx := strings.Index(ref, ":"); idx >= 0 && idx+1 < len(ref) {
+		name, op, arg = ref[:idx], ref[idx:idx+2], ref[idx+2:]
+	}
+	if name == "" {
+		return "", fmt.Errorf("empty variable reference ${%s}", ref)
+	}
+	v, ok := lookup(name)
+	switch op {
+	case "":
+		if !ok && strict {
+			return "", fmt.Errorf("${%s} is not set", name)
+		}
+		return v, nil
+	case ":-":
+		if ok && v != "" {
+			return v, nil
+		}
+		return expand(arg, lookup, strict)
+	case ":?":
+		if ok && v != "" {
+			return v, nil
+		}
+		if arg == "" {
+			arg = "required variable is not set"
+		}
+		return "", fmt.Errorf("%s: %s", name, arg)
+	default:
+		return "", fmt.Errorf("unsupported variable reference ${%s}", ref)
+	}
+}
+
+// closingBrace returns the index of the '}' that closes the reference
+// starting at i, allowing nested references in default values.
+func closingBrace(s string, i int) int {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
@@ -0,0 +1,104 @@
+package encode
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{
+		"HOST":  "localhost",
+		"PORT":  "5432",
+		"EMPTY": "",
+	}
+	fn := func(in string) (string, error) {
+		return Expand(in, func(k string) (string, bool) {
+			v, ok := vars[k]
+			return v, ok
+		})
+	}
+	cases := trial.Cases[string, string]{
+		"no reference": {
+			Input:    "plain",
+			Expected: "plain",
+		},
+		"variable": {
+			Input:    "${HOST}:${PORT}",
+			Expected: "localhost:5432",
+		},
+		"unset variable": {
+			Input:    "a${MISSING}b",
+			Expected: "ab",
+		},
+		"default": {
+			Input:    "${MISSING:-other}:${PORT:-80}",
+			Expected: "other:5432",
+		},
+		"default for empty": {
+			Input:    "${EMPTY:-x}",
+			Expected: "x",
+		},
+		"nested default": {
+			Input:    "${MISSING:-${HOST}}",
+			Expected: "localhost",
+		},
+		"required set": {
+			Input:    "${HOST:?host is required}",
+			Expected: "localhost",
+		},
+		"required unset": {
+			Input:       "${MISSING:?must be set}",
+			ExpectedErr: errors.New("MISSING: must be set"),
+		},
+		"required default message": {
+			Input:       "${EMPTY:?}",
+			ExpectedErr: errors.New("EMPTY: required variable is not set"),
+		},
+		"escape": {
+			Input:    "$${HOST} costs $$5",
+			Expected: "${HOST} costs $5",
+		},
+		"bare dollar": {
+			Input:    "pa$word$",
+			Expected: "pa$word$",
+		},
+		"unterminated": {
+			Input:       "${HOST",
+			ExpectedErr: errors.New("unterminated variable reference"),
+		},
+		"unsupported": {
+			Input:       "${HOST:+x}",
+			ExpectedErr: errors.New("unsupported variable reference"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestExpandStrict(t *testing.T) {
+	fn := func(in string) (string, error) {
+		return ExpandStrict(in, func(k string) (string, bool) {
+			return "localhost", k == "HOST"
+		})
+	}
+	cases := trial.Cases[string, string]{
+		"variable": {
+			Input:    "${HOST}",
+			Expected: "localhost",
+		},
+		"unset variable": {
+			Input:       "a${MISSING}b",
+			ExpectedErr: errors.New("${MISSING} is not set"),
+		},
+		"default": {
+			Input:    "${MISSING:-${HOST}}",
+			Expected: "localhost",
+		},
+		"unset default": {
+			Input:       "${MISSING:-${OTHER}}",
+			ExpectedErr: errors.New("${OTHER} is not set"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
@@ -0,0 +1,45 @@
+package encode
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UnmarshalKeys sets the fields of the struct pointer v from values keyed by
+// their dotted file key (see Field.Key) for flat formats (ie ini and properties).
+// Keys are matched case-insensitively and each value is set with SetField.
+// A nil struct pointer is only allocated if one of its fields is set.
+func UnmarshalKeys(values map[string]string, v interface{}) error {
+	return unmarshalKeys(values, v, SetField)
+}
+
+// UnmarshalLists is the same as UnmarshalKeys for formats with list values
+// (ie hcl). Each list is set with SetList so elements may contain commas.
+func UnmarshalLists(lists map[string][]string, v interface{}) error {
+	return unmarshalKeys(lists, v, SetList)
+}
+
+func unmarshalKeys[V any](values map[string]V, v interface{}, set func(reflect.Value, V, reflect.StructField) error) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("'%v' must be a non-nil pointer struct", reflect.TypeOf(v))
+	}
+	lower := make(map[string]V, len(values))
+	for k, val := range values {
+		lower[strings.ToLower(k)] = val
+	}
+	for _, f := range Fields(v, ".") {
+		if f.Key == "" || f.Value.Kind() == reflect.Map {
+			continue
+		}
+		val, ok := lower[strings.ToLower(f.Key)]
+		if !ok {
+			continue
+		}
+		if err := set(f.Alloc(), val, f.Struct); err != nil {
+			return fmt.Errorf("'%v' from '%s' cannot be set to %s (%s) %v", val, f.Key, f.Struct.Name, f.Value.Type(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package encode
+
+import (
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestUnmarshalKeys(t *testing.T) {
+	type db struct {
+		Host string
+		Pass string `toml:"password"`
+	}
+	type config struct {
+		Name    string
+		Ignored string `config:"ignore"`
+		Skip    string `toml:"-"`
+		DB      db
+		Backup  *db
+		Unset   *db
+	}
+	fn := func(in map[string]string) (*config, error) {
+		c := &config{Name: "default"}
+		err := UnmarshalKeys(in, c)
+		return c, err
+	}
+	cases := trial.Cases[map[string]string, *config]{
+		"keys": {
+			Input: map[string]string{"ignored": "x", "skip": "x", "DB.Host": "localhost", "db.password": "pw", "backup.host": "backup"},
+			Expected: &config{
+				Name:   "default",
+				DB:     db{Host: "localhost", Pass: "pw"},
+				Backup: &db{Host: "backup"},
+			},
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestUnmarshalLists(t *testing.T) {
+	type config struct {
+		Tags  []string
+		Ports [2]int
+		Name  string
+	}
+	fn := func(in map[string][]string) (*config, error) {
+		c := &config{Tags: []string{"default"}}
+		err := UnmarshalLists(in, c)
+		return c, err
+	}
+	cases := trial.Cases[map[string][]string, *config]{
+		"lists": {
+			Input:    map[string][]string{"Tags": {"a,b", "c"}, "ports": {"80", "443"}, "name": {"x", "y"}},
+			Expected: &config{Tags: []string{"a,b", "c"}, Ports: [2]int{80, 443}, Name: "x,y"},
+		},
+		"empty": {
+			Input:    map[string][]string{"tags": {}},
+			Expected: &config{Tags: []string{"default"}},
+		},
+		"array length": {
+			Input:     map[string][]string{"ports": {"80"}},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
@@ -0,0 +1,166 @@
+package properties
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// Unmarshal decodes the Java properties document b into the struct pointer v.
+// Dotted keys map to nested structs (ie db.host) and are matched
+// case-insensitively to the file keys (see encode.UnmarshalKeys).
+func Unmarshal(b []byte, v interface{}) error {
+	values, err := Parse(b)
+	if err != nil {
+		return err
+	}
+	return encode.UnmarshalKeys(values, v)
+}
+
+// Parse reads the properties document b into a map of keys and values.
+//
+// Lines starting with '#' or '!' are comments. A line ending in an unescaped
+// '\' continues on the next line with its leading whitespace removed. The key
+// ends at the first unescaped '=', ':' or whitespace and the value is the rest
+// of the line. Keys and values support the \t \n \r \f and \uXXXX escapes.
+// Later keys override earlier keys of the same name.
+func Parse(b []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	lineNo, start := 0, 0
+	logical := ""
+	add := func() error {
+		key, val := split(logical)
+		k, err := unescape(key)
+		if err == nil {
+			values[k], err = unescape(val)
+		}
+		if err != nil {
+			return fmt.Errorf("properties: line %d: %w", start, err)
+		}
+		return nil
+	}
+
+	continued := false
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimLeft(sc.Text(), " \t\f")
+		if !continued {
+			if line == "" || line[0] == '#' || line[0] == '!' {
+				continue
+			}
+			logical, start = "", lineNo
+		}
+		if continued = trailingEscapes(line)%2 == 1; continued {
+			logical += line[:len(line)-1]
+			continue
+		}
+		logical += line
+		if err := add(); err != nil {
+			return nil, err
+		}
+	}
+	// a continuation on the last line ends the value
+	if continued {
+		if err := add(); err != nil {
+			return nil, err
+		}
+	}
+	return values, sc.Err()
+}
+
+// trailingEscapes counts the backslashes at the end of line
+func trailingEscapes(line string) int {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n
+}
+
+// split the logical line into the escaped key and value. The key ends at
+// the first unescaped separator and the separator may be surrounded by whitespace.
+func split(line string) (key, val string) {
+	i := 0
+	for ; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+	}
+	if i > len(line) {
+		i = len(line)
+	}
+	key, rest := line[:i], strings.TrimLeft(line[i:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+// unescape the \t \n \r \f and \uXXXX escapes of s. Any other escaped
+// character is itself (ie \= is =). UTF-16 surrogate pairs are combined.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			r, err := hexRune(s, i+1)
+			if err != nil {
+				return "", err
+			}
+			i += 4
+			// combine a surrogate pair (ie \ud83d\ude00)
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+				if r2, err := hexRune(s, i+3); err == nil {
+					if dec := utf16.DecodeRune(r, r2); dec != unicode.ReplacementChar {
+						r = dec
+						i += 6
+					}
+				}
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// hexRune parses the 4 hex digits of s starting at i
+func hexRune(s string, i int) (rune, error) {
+	if i+4 > len(s) {
+		return 0, fmt.Errorf("malformed \\uxxxx escape")
+	}
+	n, err := strconv.ParseUint(s[i:i+4], 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("malformed \\uxxxx escape %q", s[i:i+4])
+	}
+	return rune(n), nil
+}
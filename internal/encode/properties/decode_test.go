@@ -0,0 +1,64 @@
+package properties
+
+import (
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestParse(t *testing.T) {
+	fn := func(in string) (map[string]string, error) {
+		return Parse([]byte(in))
+	}
+	cases := trial.Cases[string, map[string]string]{
+		"separators": {
+			Input:    "a=1\nb = 2\nc:3\nd 4\ne\n  f  =  6  \n",
+			Expected: map[string]string{"a": "1", "b": "2", "c": "3", "d": "4", "e": "", "f": "6  "},
+		},
+		"comments": {
+			Input:    "# comment\n! comment\n  # indented\nname = app # not a comment\n",
+			Expected: map[string]string{"name": "app # not a comment"},
+		},
+		"continuation": {
+			Input:    "list = a,\\\n       b,\\\n       c\nslash = c:\\\\\nlast = x\\",
+			Expected: map[string]string{"list": "a,b,c", "slash": `c:\`, "last": "x"},
+		},
+		"escapes": {
+			Input:    "key\\ with\\=sep = tab\\there\ncafe = caf\\u00e9\nsmile = \\ud83d\\ude00\nother = \\q\n",
+			Expected: map[string]string{"key with=sep": "tab\there", "cafe": "café", "smile": "😀", "other": "q"},
+		},
+		"malformed unicode": {
+			Input:     "name = \\u00zz\n",
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestUnmarshal(t *testing.T) {
+	type db struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		Name string
+		Tags []string
+		DB   *db
+	}
+	fn := func(in string) (*config, error) {
+		c := &config{Name: "default"}
+		err := Unmarshal([]byte(in), c)
+		return c, err
+	}
+	cases := trial.Cases[string, *config]{
+		"nested": {
+			Input:    "tags = a,b\ndb.host = localhost\nDB.Port = 5432\n",
+			Expected: &config{Name: "default", Tags: []string{"a", "b"}, DB: &db{Host: "localhost", Port: 5432}},
+		},
+		"invalid value": {
+			Input:     "db.port = abc\n",
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
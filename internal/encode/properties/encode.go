@@ -0,0 +1,79 @@
+package properties
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// Marshal encodes the struct pointer v as a Java properties document with a
+// dotted key for each field (ie db.host). The comment tag of a field is written
+// as a '#' comment above its key followed by '# required' for required fields.
+// Fields of each nested struct are separated by a blank line.
+func Marshal(v interface{}) ([]byte, error) {
+	if value := reflect.ValueOf(v); value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("'%v' must be a non-nil pointer struct", reflect.TypeOf(v))
+	}
+	buf := &bytes.Buffer{}
+	group := ""
+	for _, f := range encode.Fields(v, ".") {
+		if f.Key == "" || f.Value.Kind() == reflect.Map {
+			continue
+		}
+		g := ""
+		if i := strings.LastIndex(f.Key, "."); i >= 0 {
+			g = f.Key[:i]
+		}
+		if g != group && buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		group = g
+
+		if c := f.Struct.Tag.Get(encode.DescTag); c != "" {
+			fmt.Fprintf(buf, "# %s\n", c)
+		}
+		if f.Struct.Tag.Get(encode.ReqTag) == "true" {
+			buf.WriteString("# required\n")
+		}
+		fmt.Fprintf(buf, "%s=%s\n", escape(f.Key, true), escape(encode.FieldString(f.Value, f.Struct), false))
+	}
+	return buf.Bytes(), nil
+}
+
+// escape the special characters of s. Keys also escape spaces, the separators
+// and comment characters while values only escape a leading space. Characters
+// outside of printable ascii are written as \uXXXX so the document can be
+// read as ISO-8859-1.
+func escape(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == ' ' && (key || i == 0):
+			b.WriteString(`\ `)
+		case key && strings.ContainsRune("=:#!", r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, u := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%04x`, u)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
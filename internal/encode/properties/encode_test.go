@@ -0,0 +1,55 @@
+package properties
+
+import (
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestMarshal(t *testing.T) {
+	type db struct {
+		Host string `req:"true" comment:"database host"`
+		Port int
+	}
+	type config struct {
+		Name  string `comment:"app name"`
+		Tags  []string
+		Value string `toml:"a key"`
+		DB    db
+	}
+	fn := func(in interface{}) (string, error) {
+		b, err := Marshal(in)
+		return string(b), err
+	}
+	cases := trial.Cases[interface{}, string]{
+		"template": {
+			Input: &config{Name: "app", Tags: []string{"a", "b"}, Value: " x=y\tcafé", DB: db{Port: 5432}},
+			Expected: "# app name\nname=app\ntags=a,b\na\\ key=\\ x=y\\tcaf\\u00e9\n\n" +
+				"# database host\n# required\ndb.host=\ndb.port=5432\n",
+		},
+		"not a pointer": {
+			Input:     config{},
+			ShouldErr: true,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestRoundTrip(t *testing.T) {
+	type config struct {
+		Name  string
+		Value string `toml:"a:key"`
+	}
+	in := &config{Name: " lead\\ing", Value: "line\nbreak 😀"}
+	b, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &config{}
+	if err := Unmarshal(b, out); err != nil {
+		t.Fatal(err)
+	}
+	if eq, diff := trial.Equal(out, in); !eq {
+		t.Error(diff)
+	}
+}
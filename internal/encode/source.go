@@ -0,0 +1,125 @@
+package encode
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Sources returns the sources allowed to set the field by its source tag
+// (ie `source:"env,file"`). A field without the tag takes on the sources of
+// its parent struct. nil allows all sources.
+func Sources(sField reflect.StructField, parent []string) []string {
+	tag := sField.Tag.Get(SourceTag)
+	if tag == "" {
+		return parent
+	}
+	list := []string{}
+	for _, s := range strings.Split(tag, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// SourceAllowed reports if source is one of the allowed sources.
+// nil allows all sources.
+func SourceAllowed(allowed []string, source string) bool {
+	if allowed == nil {
+		return true
+	}
+	for _, s := range allowed {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Restrict returns a copy of the struct pointer v without the fields whose
+// allowed sources (see Sources) are rejected by keep. v is returned as is
+// when no field is removed. The copy is a new struct type with the same
+// field names and tags so it can be passed to an encoder.
+func Restrict(v interface{}, keep func(allowed []string) bool) interface{} {
+	rejected := make(map[string]bool)
+	for _, f := range Fields(v, ".") {
+		if !keep(f.Sources) {
+			rejected[f.Path] = true
+		}
+	}
+	if len(rejected) == 0 {
+		return v
+	}
+	value := reflect.ValueOf(v).Elem()
+	out := restrict(value, "", rejected, TypePath{value.Type()})
+	p := reflect.New(out.Type())
+	p.Elem().Set(out)
+	return p.Interface()
+}
+
+// restrict rebuilds vStruct at the field path without the rejected fields (see Fields).
+// Private fields are not copied as they are never encoded. Nested structs are
+// only rebuilt when they have a rejected field and are removed when no fields are left.
+func restrict(vStruct reflect.Value, path string, rejected map[string]bool, types TypePath) reflect.Value {
+	var sFields []reflect.StructField
+	var values []reflect.Value
+	for i := 0; i < vStruct.NumField(); i++ {
+		field := vStruct.Field(i)
+		sField := vStruct.Type().Field(i)
+		if sField.PkgPath != "" {
+			continue
+		}
+		fieldPath := join(path, sField.Name, ".")
+
+		switch {
+		case rejected[fieldPath]:
+			continue
+		case IsNested(field.Type()) && !types.Contains(field.Type()) && hasPrefix(rejected, fieldPath+"."):
+			isPtr := field.Kind() == reflect.Ptr
+			elem := field
+			if isPtr {
+				if field.IsNil() {
+					elem = reflect.New(field.Type().Elem())
+				}
+				elem = elem.Elem()
+			}
+			sub := restrict(elem, fieldPath, rejected, types.Add(field.Type()))
+			if sub.NumField() == 0 {
+				continue
+			}
+			switch {
+			case isPtr && field.IsNil():
+				field = reflect.Zero(reflect.PtrTo(sub.Type()))
+			case isPtr:
+				field = reflect.New(sub.Type())
+				field.Elem().Set(sub)
+			default:
+				field = sub
+			}
+			sField.Type = field.Type()
+		}
+
+		// promoted methods of embedded fields are not supported by StructOf
+		if sField.Anonymous && (sField.Type.NumMethod() > 0 || reflect.PtrTo(sField.Type).NumMethod() > 0) {
+			sField.Anonymous = false
+		}
+		sField.Index, sField.Offset = nil, 0
+		sFields = append(sFields, sField)
+		values = append(values, field)
+	}
+	out := reflect.New(reflect.StructOf(sFields)).Elem()
+	for i, v := range values {
+		out.Field(i).Set(v)
+	}
+	return out
+}
+
+// hasPrefix reports if one of the paths starts with prefix
+func hasPrefix(paths map[string]bool, prefix string) bool {
+	for p := range paths {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package encode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestRestrict(t *testing.T) {
+	type db struct {
+		Username string
+		Password string `source:"env"`
+	}
+	type config struct {
+		Host    string
+		Token   string `source:"env,flag"`
+		DB      db
+		Secrets *db `source:"env"`
+		private string
+	}
+	fn := func(source string) (string, error) {
+		c := Restrict(&config{Host: "localhost", DB: db{Username: "admin"}}, func(allowed []string) bool {
+			return SourceAllowed(allowed, source)
+		})
+		b, err := json.Marshal(c)
+		return string(b), err
+	}
+	cases := trial.Cases[string, string]{
+		"file": {
+			Input:    "file",
+			Expected: `{"Host":"localhost","DB":{"Username":"admin"}}`,
+		},
+		"env": {
+			Input:    "env",
+			Expected: `{"Host":"localhost","Token":"","DB":{"Username":"admin","Password":""},"Secrets":null}`,
+		},
+		"flag": {
+			Input:    "flag",
+			Expected: `{"Host":"localhost","Token":"","DB":{"Username":"admin"}}`,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestSourceAllowed(t *testing.T) {
+	fn := func(in []string) (bool, error) {
+		return SourceAllowed(in, "env"), nil
+	}
+	cases := trial.Cases[[]string, bool]{
+		"all":     {Input: nil, Expected: true},
+		"none":    {Input: []string{}, Expected: false},
+		"allowed": {Input: []string{"file", "env"}, Expected: true},
+		"denied":  {Input: []string{"file", "flag"}, Expected: false},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
@@ -153,6 +153,40 @@ func SetField(value reflect.Value, s string, sField reflect.StructField) error {
 	return nil
 }
 
+// SetList sets the slice or array value from the list of elements. Each
+// element is set with SetField so it may contain commas. Other types are
+// set to the elements joined with a comma. An empty list is not set.
+func SetList(value reflect.Value, list []string, sField reflect.StructField) error {
+	if len(list) == 0 || !value.CanSet() {
+		return nil
+	}
+	if implementsUnmarshaler(reflect.New(value.Type())) {
+		return SetField(value, strings.Join(list, ","), sField)
+	}
+	switch value.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(value.Type(), len(list), len(list))
+		for i, s := range list {
+			if err := SetField(slice.Index(i), s, sField); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	case reflect.Array:
+		if value.Len() != len(list) {
+			return fmt.Errorf("cannot set array of different lengths got %d want %d", value.Len(), len(list))
+		}
+		for i, s := range list {
+			if err := SetField(value.Index(i), s, sField); err != nil {
+				return err
+			}
+		}
+	default:
+		return SetField(value, strings.Join(list, ","), sField)
+	}
+	return nil
+}
+
 // isZero checks if the value s is the zero value of type t
 func isZero(t reflect.Kind, s string) bool {
 	switch t {
@@ -171,6 +205,23 @@ func isZero(t reflect.Kind, s string) bool {
 // tFmt can be any time package handy time format like "RFC3339Nano".
 // Default format is time.RFC3339.
 func SetTime(value reflect.Value, tv, timeFmt string) (string, error) {
+	timeFmt = TimeFormat(timeFmt)
+
+	t, err := time.Parse(timeFmt, tv)
+	if err != nil {
+		return timeFmt, err
+	}
+
+	tStruct := reflect.ValueOf(t)
+	value.Set(tStruct)
+
+	return timeFmt, nil
+}
+
+// TimeFormat returns the layout for the time format tag value.
+// A time package format name (ie "RFC3339Nano") is converted to its layout
+// and an empty value defaults to time.RFC3339.
+func TimeFormat(timeFmt string) string {
 	if timeFmt == "" {
 		timeFmt = time.RFC3339 // default format
 	}
@@ -208,16 +259,170 @@ func SetTime(value reflect.Value, tv, timeFmt string) (string, error) {
 	case "StampNano":
 		timeFmt = time.StampNano
 	}
+	return timeFmt
+}
 
-	t, err := time.Parse(timeFmt, tv)
-	if err != nil {
-		return timeFmt, err
+// FieldString returns the string representation of the field's value
+// in the same format expected when setting it. Nil pointers are empty.
+func FieldString(value reflect.Value, sField reflect.StructField) string {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		vals := make([]string, value.Len())
+		for i := range vals {
+			vals[i] = FieldString(value.Index(i), sField)
+		}
+		return strings.Join(vals, ",")
 	}
+	switch v := value.Interface().(type) {
+	case time.Time:
+		return v.Format(TimeFormat(sField.Tag.Get(FormatTag)))
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		if err != nil {
+			return fmt.Sprint(value.Interface())
+		}
+		return string(b)
+	}
+	return fmt.Sprint(value.Interface())
+}
 
-	tStruct := reflect.ValueOf(t)
-	value.Set(tStruct)
+// ReplaceStrings calls fn with every string within v (including pointers,
+// slices, arrays and map values) and sets the string to the result.
+func ReplaceStrings(v reflect.Value, fn func(string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := fn(v.String())
+		if err != nil {
+			return err
+		}
+		if v.CanSet() {
+			v.SetString(s)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return ReplaceStrings(v.Elem(), fn)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := ReplaceStrings(v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			s, err := fn(iter.Value().String())
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), reflect.ValueOf(s).Convert(v.Type().Elem()))
+		}
+	}
+	return nil
+}
 
-	return timeFmt, nil
+// Escape returns a copy of the struct pointer v where every '$' in the string
+// values that are expanded when read from a config file (see ReplaceStrings)
+// is escaped as "$$", so a template of v reads back as the same values.
+func Escape(v interface{}) interface{} {
+	return copyConfig(v, func(s string) string { return strings.ReplaceAll(s, "$", "$$") })
+}
+
+// Copy returns a deep copy of the struct pointer v that does not share its
+// nested struct pointers, slices, maps or pointers with v.
+func Copy(v interface{}) interface{} {
+	return copyConfig(v, func(s string) string { return s })
+}
+
+// copyConfig returns a deep copy of the struct pointer v with fn
+// applied to the strings that ReplaceStrings replaces.
+func copyConfig(v interface{}, fn func(string) string) interface{} {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return v
+	}
+	out := reflect.New(value.Elem().Type())
+	out.Elem().Set(copyStruct(value.Elem(), TypePath{value.Elem().Type()}, fn))
+	return out.Interface()
+}
+
+// copyStruct copies vStruct applying fn to the values of its fields and nested structs.
+func copyStruct(vStruct reflect.Value, types TypePath, fn func(string) string) reflect.Value {
+	out := reflect.New(vStruct.Type()).Elem()
+	out.Set(vStruct)
+	for i := 0; i < out.NumField(); i++ {
+		field := out.Field(i)
+		switch {
+		case !field.CanSet() || (IsNested(field.Type()) && types.Contains(field.Type())):
+		case IsNested(field.Type()) && field.Kind() == reflect.Ptr:
+			if !field.IsNil() {
+				p := reflect.New(field.Type().Elem())
+				p.Elem().Set(copyStruct(field.Elem(), types.Add(field.Type()), fn))
+				field.Set(p)
+			}
+		case IsNested(field.Type()):
+			field.Set(copyStruct(field, types.Add(field.Type()), fn))
+		default:
+			field.Set(copyValue(field, fn))
+		}
+	}
+	return out
+}
+
+// copyValue returns a copy of v with fn applied to the
+// strings that ReplaceStrings replaces.
+func copyValue(v reflect.Value, fn func(string) string) reflect.Value {
+	switch v.Kind() {
+	case reflect.String:
+		out := reflect.New(v.Type()).Elem()
+		out.SetString(fn(v.String()))
+		return out
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(copyValue(v.Elem(), fn))
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(copyValue(v.Index(i), fn))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(copyValue(v.Index(i), fn))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		if v.Type().Elem().Kind() != reflect.String {
+			// only string map values are replaced
+			fn = func(s string) string { return s }
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), copyValue(iter.Value(), fn))
+		}
+		return out
+	}
+	return v
 }
 
 func implementsUnmarshaler(v reflect.Value) bool {
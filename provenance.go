@@ -0,0 +1,229 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+	"github.com/hydronica/go-config/internal/encode/env"
+	"github.com/hydronica/go-config/internal/encode/file"
+)
+
+// SourceKind is the type of source a config value was loaded from.
+type SourceKind string
+
+const (
+	SourceDefault   SourceKind = "default"   // value set on the struct before Load
+	SourceEnv       SourceKind = "env"       // process environment variable
+	SourceEnvFile   SourceKind = ".env"      // working directory .env file
+	SourceConfigMap SourceKind = "configmap" // Kubernetes ConfigMap or Secret directory
+	SourceFile      SourceKind = "file"      // config file (toml, yaml, json)
+	SourceFlag      SourceKind = "flag"      // command line flag
+)
+
+// Origin is a value and the source that set it.
+type Origin struct {
+	Kind SourceKind
+
+	// Detail describes where in the source the value came from.
+	// ie: "config.toml:12", "DB_UN" or "-db.un"
+	Detail string
+
+	Value string
+
+	// Redacted is set for fields with the `show:"false"` tag.
+	// The Value is not printed by String.
+	Redacted bool
+}
+
+func (o Origin) String() string {
+	if o.Detail == "" {
+		return fmt.Sprintf("%s %s", o.Kind, o.value())
+	}
+	return fmt.Sprintf("%s %s %s", o.Kind, o.Detail, o.value())
+}
+
+// value is the quoted value or [redacted]
+func (o Origin) value() string {
+	if o.Redacted {
+		return redacted
+	}
+	return strconv.Quote(o.Value)
+}
+
+// Provenance records where a config field's value was loaded from
+// and all of the values it replaced.
+type Provenance struct {
+	Path string // struct field path (ie DB.Username)
+
+	Origin // current value and its source
+
+	// Overridden values in the order they were loaded.
+	// The first is always the default value.
+	Overridden []Origin
+}
+
+func (p Provenance) String() string {
+	s := fmt.Sprintf("%s: %s from %s", p.Path, p.value(), p.Kind)
+	if p.Detail != "" {
+		s += " " + p.Detail
+	}
+	if len(p.Overridden) == 0 {
+		return s
+	}
+	o := make([]string, len(p.Overridden))
+	for i := range p.Overridden {
+		// most recent first
+		o[i] = p.Overridden[len(p.Overridden)-1-i].String()
+	}
+	return s + " (overrides " + strings.Join(o, ", ") + ")"
+}
+
+// Provenance returns the source of every config field after Load.
+// Fields are in struct order and nil before Load is called.
+func (g *goConfig) Provenance() []Provenance {
+	if g.provenance == nil {
+		return nil
+	}
+	list := make([]Provenance, 0, len(g.provenance))
+	for _, f := range encode.Fields(g.config, g.flagSep) {
+		if p, ok := g.provenance[f.Path]; ok {
+			list = append(list, *p)
+		}
+	}
+	return list
+}
+
+// ProvenanceOf returns the source of the config field at path (ie "DB.Username").
+func (g *goConfig) ProvenanceOf(path string) (Provenance, bool) {
+	p, ok := g.provenance[path]
+	if !ok {
+		return Provenance{}, false
+	}
+	return *p, true
+}
+
+// ProvenanceOf returns the source of the config field at path after
+// Load or LoadOrDie (ie "DB.Username").
+func ProvenanceOf(path string) (Provenance, bool) {
+	return defaultCfg.ProvenanceOf(path)
+}
+
+// trackDefaults records the current value of all fields as the default.
+func (g *goConfig) trackDefaults() {
+	g.provenance = make(map[string]*Provenance)
+	for _, f := range encode.Fields(g.config, g.flagSep) {
+		g.provenance[f.Path] = &Provenance{
+			Path: f.Path,
+			Origin: Origin{
+				Kind:     SourceDefault,
+				Value:    encode.FieldString(f.Value, f.Struct),
+				Redacted: f.Struct.Tag.Get(encode.ShowTag) == "false",
+			},
+		}
+	}
+}
+
+// locator returns the location of a field within a source (see Origin.Detail)
+// and if the source has the field. A source that cannot tell returns false.
+type locator func(encode.Field) (detail string, found bool)
+
+// track runs the load func and attributes every changed field to the source.
+// locate returns the location of the field within the source.
+//
+// A field that may not be set by the source (see the source tag) is an error
+// when the source has the field or changed its value.
+//
+// Note: a source that sets a field to its current value is not recorded.
+func (g *goConfig) track(kind SourceKind, locate locator, load func() error) error {
+	if err := load(); err != nil {
+		return err
+	}
+	for _, f := range encode.Fields(g.config, g.flagSep) {
+		v := encode.FieldString(f.Value, f.Struct)
+		p, ok := g.provenance[f.Path]
+		changed := !ok || p.Value != v
+		allowed := encode.SourceAllowed(f.Sources, string(kind))
+		if !changed && allowed {
+			continue
+		}
+		detail, found := locate(f)
+		if !allowed && (changed || found) {
+			return fmt.Errorf("%s may not be set from %s (allowed sources: %s)",
+				f.Path, strings.TrimSpace(string(kind)+" "+detail), strings.Join(f.Sources, ","))
+		}
+		if !changed {
+			continue
+		}
+		origin := Origin{Kind: kind, Detail: detail, Value: v, Redacted: f.Struct.Tag.Get(encode.ShowTag) == "false"}
+		if !ok {
+			g.provenance[f.Path] = &Provenance{Path: f.Path, Origin: origin}
+			continue
+		}
+		p.Overridden = append(p.Overridden, p.Origin)
+		p.Origin = origin
+	}
+	return nil
+}
+
+// envDetail is the env variable name of the process env that set the
+// value including the _FILE suffix when the value was read from a file.
+func (g *goConfig) envDetail(f encode.Field) (string, bool) {
+	if f.Env == "" {
+		return "", false
+	}
+	names := g.envDecoder().LookupNames(f.Env, f.EnvAliases)
+	for _, n := range names {
+		if os.Getenv(n) != "" {
+			return n, true
+		}
+		if g.options.isEnabled(OptEnvFileSuffix) && os.Getenv(n+env.FileSuffix) != "" {
+			return n + env.FileSuffix, true
+		}
+	}
+	return names[0], false
+}
+
+// flagDetail is the flag name, found when the flag is on the command line
+func (g *goConfig) flagDetail(f encode.Field) (string, bool) {
+	return "-" + f.Flag, g.flags != nil && f.Flag != "" && g.flags.IsSet(f.Flag)
+}
+
+// fileDetail returns the file path and line number of a key in the file
+// (ie config.toml:12). The last of the file and its includes that defines
+// the key is used, preferring the key of the selected profile.
+//
+// The field is found when one of the files has the key once decoded (see file.Loader.Keys).
+func (g *goConfig) fileDetail(path string, key func(encode.Field) string) locator {
+	l := g.fileLoader()
+	files := l.Files(path, g.config)
+	profile := g.profileName()
+	keys := make([]map[string]bool, len(files)) // decoded when first needed
+	return func(f encode.Field) (string, bool) {
+		found := false
+		for i := range files {
+			if keys[i] == nil {
+				keys[i], _ = l.Keys(files[i], profile, g.config)
+			}
+			found = found || l.HasField(keys[i], files[i], f)
+		}
+		for i := len(files) - 1; i >= 0; i-- {
+			if line := file.KeyLineProfile(files[i], profile, key(f)); line > 0 {
+				return files[i] + ":" + strconv.Itoa(line), found
+			}
+		}
+		return path, found
+	}
+}
+
+// fileKey returns the key used to find a field in the config file.
+// .env files are keyed by env name with the EnvPrefix, all other files by the dotted file key.
+func (g *goConfig) fileKey(path string) func(encode.Field) string {
+	if filepath.Ext(path) == ".env" {
+		return func(f encode.Field) string { return g.envName(f.Env) }
+	}
+	return func(f encode.Field) string { return f.Key }
+}
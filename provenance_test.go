@@ -0,0 +1,149 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hydronica/trial"
+)
+
+func TestGoConfig_Provenance(t *testing.T) {
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		os.Unsetenv("NAME")
+		os.Unsetenv("VALUE")
+	}()
+	os.Setenv("NAME", "env")
+	os.Setenv("VALUE", "8")
+	os.Args = []string{"go-config", "-c=test/test.toml", "-name=flag"}
+
+	c := testStruct{Dura: time.Second, Uint: 2}
+	g := New(&c).Disable(OptEnvFile)
+	if err := g.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := trial.Cases[string, Provenance]{
+		"default": {
+			Input: "Uint",
+			Expected: Provenance{
+				Path:   "Uint",
+				Origin: Origin{Kind: SourceDefault, Value: "2"},
+			},
+		},
+		"file": {
+			Input: "Value",
+			Expected: Provenance{
+				Path:   "Value",
+				Origin: Origin{Kind: SourceFile, Detail: "test/test.toml:2", Value: "10"},
+				Overridden: []Origin{
+					{Kind: SourceDefault, Value: "0"},
+					{Kind: SourceEnv, Detail: "VALUE", Value: "8"},
+				},
+			},
+		},
+		"flag": {
+			Input: "Name",
+			Expected: Provenance{
+				Path:   "Name",
+				Origin: Origin{Kind: SourceFlag, Detail: "-name", Value: "flag"},
+				Overridden: []Origin{
+					{Kind: SourceDefault, Value: ""},
+					{Kind: SourceEnv, Detail: "NAME", Value: "env"},
+					{Kind: SourceFile, Detail: "test/test.toml:1", Value: "toml"},
+				},
+			},
+		},
+		"nested": {
+			Input: "Pointer.Count",
+			Expected: Provenance{
+				Path:   "Pointer.Count",
+				Origin: Origin{Kind: SourceDefault},
+			},
+		},
+		"unknown": {
+			Input:     "Missing",
+			ShouldErr: true,
+		},
+	}
+	trial.New(func(path string) (Provenance, error) {
+		p, ok := g.ProvenanceOf(path)
+		if !ok {
+			return p, os.ErrNotExist
+		}
+		return p, nil
+	}, cases).SubTest(t)
+
+	if got := len(g.Provenance()); got != 10 {
+		t.Errorf("expected provenance for 10 fields got %d", got)
+	}
+}
+
+func TestGoConfig_ProvenanceDetail(t *testing.T) {
+	type input struct {
+		flags []string
+		field string
+	}
+	fn := func(in input) (string, error) {
+		defer func() { flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError) }()
+		os.Args = append([]string{"go-config"}, in.flags...)
+		g := New(&testStruct{}).Disable(OptEnv | OptEnvFile)
+		if err := g.Load(); err != nil {
+			return "", err
+		}
+		p, _ := g.ProvenanceOf(in.field)
+		return p.Detail, nil
+	}
+	cases := trial.Cases[input, string]{
+		"top-level file": {
+			Input:    input{flags: []string{"-c=test/include/main.toml"}, field: "Name"},
+			Expected: "test/include/main.toml:2",
+		},
+		"included file": {
+			Input:    input{flags: []string{"-c=test/include/main.toml"}, field: "Value"},
+			Expected: "test/include/db.yaml:2",
+		},
+		"nested include": {
+			Input:    input{flags: []string{"-c=test/include/main.toml"}, field: "Enable"},
+			Expected: "test/include/sub/enable.json:2",
+		},
+		"profile": {
+			Input:    input{flags: []string{"-c=test/profile.toml", "-profile=stage"}, field: "Value"},
+			Expected: "test/profile.toml:9",
+		},
+		"base of profile": {
+			Input:    input{flags: []string{"-c=test/profile.toml", "-profile=prod"}, field: "Value"},
+			Expected: "test/profile.toml:2",
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestProvenance_String(t *testing.T) {
+	p := Provenance{
+		Path:   "DB.Host",
+		Origin: Origin{Kind: SourceFlag, Detail: "-db.host", Value: "prod"},
+		Overridden: []Origin{
+			{Kind: SourceDefault, Value: "localhost"},
+			{Kind: SourceFile, Detail: "config.toml:3", Value: "stage"},
+		},
+	}
+	exp := `DB.Host: "prod" from flag -db.host (overrides file config.toml:3 "stage", default "localhost")`
+	if s := p.String(); s != exp {
+		t.Errorf("got %s\nwant %s", s, exp)
+	}
+
+	p = Provenance{
+		Path:   "DB.Password",
+		Origin: Origin{Kind: SourceEnv, Detail: "DB_PASSWORD", Value: "secret", Redacted: true},
+		Overridden: []Origin{
+			{Kind: SourceDefault, Value: "changeme", Redacted: true},
+		},
+	}
+	exp = `DB.Password: [redacted] from env DB_PASSWORD (overrides default [redacted])`
+	if s := p.String(); s != exp {
+		t.Errorf("got %s\nwant %s", s, exp)
+	}
+}
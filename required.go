@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// checkRequired verifies every field with the `req:"true"` tag has been set.
+// All unset fields are returned as a single error, along with the flag,
+// env variable and file key that could be used to set them.
+//
+// A nil struct pointer is optional, so its required fields are only
+// checked once one of its fields has been set (allocating the struct).
+func (g *goConfig) checkRequired() error {
+	var missing []string
+	for _, f := range encode.Fields(g.config, g.flagSep) {
+		if f.Struct.Tag.Get(encode.ReqTag) != "true" || f.Nil || !f.Value.IsZero() {
+			continue
+		}
+		missing = append(missing, f.Path+g.sourceNames(f))
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("missing required fields:\n\t%s", strings.Join(missing, "\n\t"))
+}
+
+// sourceNames lists the enabled ways the field can be set
+// ie: " (flag: -db.un, env: DB_UN, file: db.un)"
+func (g *goConfig) sourceNames(f encode.Field) string {
+	var names []string
+	if f.Flag != "" && g.options.isEnabled(OptFlag) && encode.SourceAllowed(f.Sources, string(SourceFlag)) {
+		names = append(names, "flag: -"+f.Flag)
+	}
+	if f.Env != "" && (g.options.isEnabled(OptEnv) || g.options.isEnabled(OptEnvFile)) &&
+		(encode.SourceAllowed(f.Sources, string(SourceEnv)) || encode.SourceAllowed(f.Sources, string(SourceEnvFile))) {
+		names = append(names, "env: "+strings.Join(g.envNames(f), encode.EnvAliasSep))
+	}
+	if f.Key != "" && g.options.isEnabled(OptFiles) && encode.SourceAllowed(f.Sources, string(SourceFile)) {
+		names = append(names, "file: "+f.Key)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(names, ", ") + ")"
+}
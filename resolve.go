@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+// Resolver replaces a secret reference with its value. A reference is
+// a string value in the form scheme://ref (ie file:///run/secrets/db_pw)
+// and the resolver registered for the scheme is called with ref.
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc is a func that implements the Resolver interface
+type ResolverFunc func(ref string) (string, error)
+
+func (fn ResolverFunc) Resolve(ref string) (string, error) { return fn(ref) }
+
+// Resolvers are not registered by default as a reference may come from any
+// source. Register the ones needed with RegisterResolver, ie
+//
+//	RegisterResolver("file", FileResolver) // file:///path contents of the file
+//	RegisterResolver("env", EnvResolver)   // env://NAME value of the env variable NAME
+//	RegisterResolver("exec", ExecResolver) // exec://cmd arg stdout of the command
+var (
+	// FileResolver returns the contents of the file with the trailing newline removed
+	FileResolver Resolver = ResolverFunc(resolveFile)
+
+	// EnvResolver returns the value of the env variable
+	EnvResolver Resolver = ResolverFunc(resolveEnv)
+
+	// ExecResolver returns the stdout of the command with the trailing newline removed.
+	// Only register it when every source of the config is trusted as the
+	// command is run as the app's user.
+	ExecResolver Resolver = ResolverFunc(resolveExec)
+)
+
+// RegisterResolver sets the Resolver used for secret references with the scheme
+// (ie "vault" for vault://secret/db). A nil Resolver removes the scheme.
+// References are resolved after all sources are loaded and only for the
+// registered schemes.
+func (g *goConfig) RegisterResolver(scheme string, r Resolver) *goConfig {
+	if r == nil {
+		delete(g.resolvers, scheme)
+		return g
+	}
+	g.resolvers[scheme] = r
+	return g
+}
+
+// RegisterResolver sets the Resolver used for secret references with the scheme
+// when using Load or LoadOrDie.
+func RegisterResolver(scheme string, r Resolver) {
+	defaultCfg.RegisterResolver(scheme, r)
+}
+
+// resolve replaces every secret reference in the config's string values.
+// All failures are returned as a single error naming the field and reference.
+func (g *goConfig) resolve() error {
+	if len(g.resolvers) == 0 {
+		return nil
+	}
+	var errs []string
+	for _, f := range encode.Fields(g.config, g.flagSep) {
+		err := encode.ReplaceStrings(f.Value, func(s string) (string, error) {
+			r, ref, ok := g.resolver(s)
+			if !ok {
+				return s, nil
+			}
+			v, err := r.Resolve(ref)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s %q: %v", f.Path, s, err))
+				return s, nil
+			}
+			return v, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("secret reference errors:\n\t%s", strings.Join(errs, "\n\t"))
+}
+
+// resolver returns the registered Resolver and the reference
+// if s is a secret reference (scheme://ref).
+func (g *goConfig) resolver(s string) (Resolver, string, bool) {
+	i := strings.Index(s, "://")
+	if i <= 0 {
+		return nil, "", false
+	}
+	r, ok := g.resolvers[s[:i]]
+	return r, s[i+3:], ok
+}
+
+func resolveFile(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func resolveEnv(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("env variable %s is not set", name)
+	}
+	return v, nil
+}
+
+func resolveExec(command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", errors.New("empty command")
+	}
+	b, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/hydronica/go-config/internal/encode"
+)
+
+const redacted = "[redacted]"
+
+// showFlag is the -show flag value. It acts as a bool flag
+// so that -show uses the table format or the format can be
+// specified with -show=json|yaml|table
+type showFlag string
+
+func (s *showFlag) String() string { return string(*s) }
+
+func (s *showFlag) IsBoolFlag() bool { return true }
+
+func (s *showFlag) Set(v string) error {
+	switch v {
+	case "true":
+		*s = "table"
+	case "false":
+		*s = ""
+	case "table", "json", "yaml":
+		*s = showFlag(v)
+	default:
+		return fmt.Errorf("invalid show format %q must be one of table,json,yaml", v)
+	}
+	return nil
+}
+
+// showValue is a single field shown with -show
+type showValue struct {
+	Field   string     `json:"field" yaml:"field"`
+	Value   string     `json:"value" yaml:"value"`
+	Default string     `json:"default,omitempty" yaml:"default,omitempty"`
+	Source  SourceKind `json:"source" yaml:"source"`
+	Detail  string     `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// ShowValues writes the current config values to w after Load.
+// Fields with the `show:"false"` tag are redacted.
+//
+//	Host:     "myhost:5432" (default: "localhost:5432") [flag -host]
+//	Username: "myusername" [env USERNAME]
+//	Password: [redacted] [flag -password]
+func (g *goConfig) ShowValues(w io.Writer) error {
+	return g.showValues(w, "table")
+}
+
+// ShowValues writes the config values to w after Load or LoadOrDie.
+// Fields with the `show:"false"` tag are redacted.
+func ShowValues(w io.Writer) error {
+	return defaultCfg.ShowValues(w)
+}
+
+// values returns the current value of every field and its source.
+func (g *goConfig) values() []showValue {
+	var list []showValue
+	for _, f := range encode.Fields(g.config, g.flagSep) {
+		v := showValue{
+			Field:  f.Path,
+			Value:  encode.FieldString(f.Value, f.Struct),
+			Source: SourceDefault,
+		}
+		if p, ok := g.provenance[f.Path]; ok {
+			v.Source, v.Detail = p.Kind, p.Detail
+			if len(p.Overridden) > 0 && p.Overridden[0].Value != v.Value {
+				v.Default = p.Overridden[0].Value
+			}
+		}
+		if f.Struct.Tag.Get(encode.ShowTag) == "false" {
+			v.Value, v.Default = redacted, ""
+		}
+		list = append(list, v)
+	}
+	return list
+}
+
+// showValues writes all values to w in the format (table, json, yaml).
+func (g *goConfig) showValues(w io.Writer, format string) error {
+	values := g.values()
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "yaml":
+		b, err := yaml.Marshal(values)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case "table", "":
+	default:
+		return fmt.Errorf("unsupported show format %s", format)
+	}
+
+	width := 0
+	for _, v := range values {
+		if len(v.Field) > width {
+			width = len(v.Field)
+		}
+	}
+	for _, v := range values {
+		s := fmt.Sprintf("%-*s %q", width+1, v.Field+":", v.Value)
+		if v.Value == redacted {
+			s = fmt.Sprintf("%-*s %s", width+1, v.Field+":", v.Value)
+		}
+		if v.Default != "" {
+			s += fmt.Sprintf(" (default: %q)", v.Default)
+		}
+		if v.Source != SourceDefault {
+			s += " [" + strings.TrimSpace(string(v.Source)+" "+v.Detail) + "]"
+		}
+		if _, err := fmt.Fprintln(w, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
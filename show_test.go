@@ -0,0 +1,110 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+func TestGoConfig_ShowValues(t *testing.T) {
+	type db struct {
+		Username string
+		Password string `show:"false"`
+	}
+	type config struct {
+		Host string
+		DB   db
+	}
+	fn := func(format string) (string, error) {
+		c := &config{Host: "localhost:5432", DB: db{Password: "default"}}
+		g := New(c)
+		g.trackDefaults()
+		err := g.track(SourceFlag, g.flagDetail, func() error {
+			c.Host = "myhost:5432"
+			c.DB.Username = "myusername"
+			c.DB.Password = "mypassword"
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+		buf := &bytes.Buffer{}
+		err = g.showValues(buf, format)
+		return buf.String(), err
+	}
+	cases := trial.Cases[string, string]{
+		"table": {
+			Input: "table",
+			Expected: `Host:        "myhost:5432" (default: "localhost:5432") [flag -host]
+DB.Username: "myusername" [flag -db.username]
+DB.Password: [redacted] [flag -db.password]
+`,
+		},
+		"json": {
+			Input: "json",
+			Expected: `[
+  {
+    "field": "Host",
+    "value": "myhost:5432",
+    "default": "localhost:5432",
+    "source": "flag",
+    "detail": "-host"
+  },
+  {
+    "field": "DB.Username",
+    "value": "myusername",
+    "source": "flag",
+    "detail": "-db.username"
+  },
+  {
+    "field": "DB.Password",
+    "value": "[redacted]",
+    "source": "flag",
+    "detail": "-db.password"
+  }
+]
+`,
+		},
+		"yaml": {
+			Input: "yaml",
+			Expected: `- field: Host
+  value: myhost:5432
+  default: localhost:5432
+  source: flag
+  detail: -host
+- field: DB.Username
+  value: myusername
+  source: flag
+  detail: -db.username
+- field: DB.Password
+  value: '[redacted]'
+  source: flag
+  detail: -db.password
+`,
+		},
+		"invalid": {
+			Input:       "xml",
+			ExpectedErr: errors.New("unsupported show format"),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestShowFlag_Set(t *testing.T) {
+	fn := func(in string) (string, error) {
+		var s showFlag
+		err := s.Set(in)
+		return s.String(), err
+	}
+	cases := trial.Cases[string, string]{
+		"bool":    {Input: "true", Expected: "table"},
+		"false":   {Input: "false", Expected: ""},
+		"json":    {Input: "json", Expected: "json"},
+		"yaml":    {Input: "yaml", Expected: "yaml"},
+		"table":   {Input: "table", Expected: "table"},
+		"invalid": {Input: "xml", ShouldErr: true},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
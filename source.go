@@ -0,0 +1,234 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hydronica/go-config/internal/encode"
+	"github.com/hydronica/go-config/internal/encode/env"
+)
+
+// Source is a config provider that can be added to Load with AddSource.
+//
+// Unmarshal follows the same contract as the built in sources. It expects a
+// struct pointer and reads in the values it has into the underlying struct.
+// The struct will already be populated by defaults and lower priority sources
+// so values that the source does not provide must not be cleared.
+// Fields with the `config:"ignore"` tag should be skipped and a missing
+// required field is not an error as a different source may provide it.
+//
+// The values set by a Source are recorded in the provenance (see Provenance)
+// and checked with the required fields and Validator like any other source.
+type Source interface {
+	// Name of the source used in provenance and -show output (ie "vault")
+	Name() string
+
+	Unmarshal(interface{}) error
+}
+
+// Locator is optionally implemented by a Source to describe where its
+// values are read from (ie a URL) in the provenance and -show output.
+type Locator interface {
+	Location() string
+}
+
+// Priority of the built in sources. A Source is loaded after all sources
+// with a lower priority so its values take precedence over theirs.
+const (
+	PriorityEnv       = 100
+	PriorityEnvFile   = 200
+	PriorityConfigMap = 300
+	PriorityFile      = 400
+	PriorityFlag      = 500
+)
+
+// source is a registered Source and its priority.
+type source struct {
+	kind     SourceKind
+	priority int
+	offset   int // from the built in source it is anchored to (see orderedSources)
+	enabled  bool
+	load     func() error
+}
+
+// AddSource registers a Source that is loaded with the priority. Sources with
+// the same priority are loaded in the order added after the built in source.
+//
+//	config.New(&c).AddSource(mySource, config.PriorityFile+1) // after files and before flags
+func (g *goConfig) AddSource(s Source, priority int) *goConfig {
+	detail := noDetail
+	if l, ok := s.(Locator); ok {
+		detail = func(encode.Field) (string, bool) { return l.Location(), false }
+	}
+	g.sources = append(g.sources, source{
+		kind:     SourceKind(s.Name()),
+		priority: priority,
+		enabled:  true,
+		load: func() error {
+			if fs, ok := s.(fileSource); ok {
+				fs.setFileOptions(g.fileLoader(), g.profileName())
+			}
+			return g.track(SourceKind(s.Name()), detail, func() error {
+				return s.Unmarshal(g.config)
+			})
+		},
+	})
+	return g
+}
+
+// Precedence sets the order the sources are loaded in from lowest to highest
+// precedence. Each source overrides the values of the sources before it.
+// Built in sources that are not listed are not loaded and sources added with
+// AddSource may be listed by name. Otherwise an added source stays anchored to
+// the built in source below its priority (ie PriorityFile+1 is loaded right
+// after the file source wherever it is listed). Load returns an error for an
+// unknown source.
+//
+//	// env set by the orchestrator overrides the config file baked into the image
+//	config.New(&c).Precedence(config.SourceFile, config.SourceEnv, config.SourceFlag)
+func (g *goConfig) Precedence(kinds ...SourceKind) *goConfig {
+	g.precedence = kinds
+	return g
+}
+
+// orderedSources returns the built in and added sources in lowest priority order.
+func (g *goConfig) orderedSources() []source {
+	sources := []source{
+		{kind: SourceEnv, priority: PriorityEnv, enabled: g.options.isEnabled(OptEnv), load: g.loadEnv},
+		{kind: SourceEnvFile, priority: PriorityEnvFile, enabled: g.options.isEnabled(OptEnvFile), load: g.loadEnvFile},
+		{kind: SourceConfigMap, priority: PriorityConfigMap, enabled: len(g.configMapDirs) > 0, load: g.loadConfigMaps},
+		{kind: SourceFile, priority: PriorityFile, enabled: g.options.isEnabled(OptFiles), load: g.loadFiles},
+		{kind: SourceFlag, priority: PriorityFlag, enabled: g.options.isEnabled(OptFlag), load: g.loadFlags},
+	}
+	builtin := len(sources)
+	sources = append(sources, g.sources...)
+	if len(g.precedence) > 0 {
+		position := make(map[SourceKind]int)
+		for i, k := range g.precedence {
+			position[k] = i + 1
+		}
+		builtinPriority := make([]int, builtin)
+		for i := range builtinPriority {
+			builtinPriority[i] = sources[i].priority
+		}
+		for i := range sources {
+			s := &sources[i]
+			if p, ok := position[s.kind]; ok {
+				s.priority = p
+				continue
+			}
+			if i < builtin {
+				s.enabled = false
+				continue
+			}
+			// anchor to the highest listed built in source at or below its priority
+			anchor, anchorPriority := 0, 0
+			for j, b := range sources[:builtin] {
+				if p, ok := position[b.kind]; ok && builtinPriority[j] <= s.priority && builtinPriority[j] >= anchorPriority {
+					anchor, anchorPriority = p, builtinPriority[j]
+				}
+			}
+			s.priority, s.offset = anchor, s.priority-anchorPriority
+		}
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sources[i].priority != sources[j].priority {
+			return sources[i].priority < sources[j].priority
+		}
+		return sources[i].offset < sources[j].offset
+	})
+	return sources
+}
+
+// checkPrecedence returns an error if a source listed with Precedence
+// is not a built in source or the name of an added source.
+func (g *goConfig) checkPrecedence() error {
+	known := map[SourceKind]bool{
+		SourceEnv: true, SourceEnvFile: true, SourceConfigMap: true, SourceFile: true, SourceFlag: true,
+	}
+	for _, s := range g.sources {
+		known[s.kind] = true
+	}
+	for _, k := range g.precedence {
+		if !known[k] {
+			return fmt.Errorf("precedence: unknown source %q", k)
+		}
+	}
+	return nil
+}
+
+// loadSources loads the enabled sources in lowest priority order.
+func (g *goConfig) loadSources() error {
+	if err := g.checkPrecedence(); err != nil {
+		return err
+	}
+	sources := g.orderedSources()
+	if err := g.checkConfigEnv(sources); err != nil {
+		return err
+	}
+	for _, s := range sources {
+		if !s.enabled {
+			continue
+		}
+		if err := s.load(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourceOrder lists the enabled sources from lowest to highest precedence
+// ie: "default, env, .env, file, flag"
+func (g *goConfig) sourceOrder() string {
+	kinds := []string{string(SourceDefault)}
+	for _, s := range g.orderedSources() {
+		if s.enabled {
+			kinds = append(kinds, string(s.kind))
+		}
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// loadEnv loads the process env variables
+func (g *goConfig) loadEnv() error {
+	d := g.envDecoder()
+	return g.track(SourceEnv, g.envDetail, func() error {
+		return d.Unmarshal(g.config)
+	})
+}
+
+// loadEnvFile loads the .env file from the working directory if it exists
+func (g *goConfig) loadEnvFile() error {
+	if _, err := os.Stat(".env"); err != nil {
+		return nil
+	}
+	log.Println("loading .env file from working directory")
+	detail := g.fileDetail(".env", g.fileKey(".env"))
+	return g.track(SourceEnvFile, detail, func() error {
+		return g.envDecoder().LoadFile(".env", g.config)
+	})
+}
+
+// envDecoder for the process env and .env file
+func (g *goConfig) envDecoder() *env.Decoder {
+	d := env.New()
+	d.FileVars = g.options.isEnabled(OptEnvFileSuffix)
+	d.Prefix = g.envPrefix
+	d.Fallback = g.options.isEnabled(OptEnvUnprefixed)
+	return d
+}
+
+// loadFlags loads the parsed command line flags
+func (g *goConfig) loadFlags() error {
+	return g.track(SourceFlag, g.flagDetail, func() error {
+		return g.flags.Unmarshal(g.config)
+	})
+}
+
+// noDetail is used for sources without a location for each field
+func noDetail(encode.Field) (string, bool) {
+	return "", false
+}
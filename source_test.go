@@ -0,0 +1,127 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/hydronica/trial"
+)
+
+// mapSource sets the Name field from a map
+type mapSource map[string]string
+
+func (m mapSource) Name() string { return "map" }
+
+func (m mapSource) Unmarshal(v interface{}) error {
+	c, ok := v.(*testStruct)
+	if !ok {
+		return errors.New("unsupported type")
+	}
+	if name, ok := m["name"]; ok {
+		c.Name = name
+	}
+	return nil
+}
+
+func TestGoConfig_AddSource(t *testing.T) {
+	type input struct {
+		priority int
+		flags    []string
+	}
+	fn := func(in input) (string, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+		}()
+		c := testStruct{}
+		os.Args = append([]string{"go-config", "-c=test/test.toml"}, in.flags...)
+		g := New(&c).Disable(OptEnv|OptEnvFile).AddSource(mapSource{"name": "map"}, in.priority)
+		if err := g.Load(); err != nil {
+			return "", err
+		}
+		p, _ := g.ProvenanceOf("Name")
+		return p.String(), nil
+	}
+	cases := trial.Cases[input, string]{
+		"before file": {
+			Input:    input{priority: PriorityEnv},
+			Expected: `Name: "toml" from file test/test.toml:1 (overrides map "map", default "")`,
+		},
+		"after file": {
+			Input:    input{priority: PriorityFile},
+			Expected: `Name: "map" from map (overrides file test/test.toml:1 "toml", default "")`,
+		},
+		"before flag": {
+			Input:    input{priority: PriorityFile + 1, flags: []string{"-name=flag"}},
+			Expected: `Name: "flag" from flag -name (overrides map "map", file test/test.toml:1 "toml", default "")`,
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
+
+func TestGoConfig_Precedence(t *testing.T) {
+	type output struct {
+		Name   string
+		Order  string
+		Detail string
+	}
+	type input struct {
+		order    []SourceKind
+		priority int // of the map source
+	}
+	fn := func(in input) (output, error) {
+		defer func() {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+			os.Unsetenv("NAME")
+		}()
+		os.Setenv("NAME", "env")
+		c := testStruct{}
+		os.Args = []string{"go-config", "-c=test/test.toml", "-name=flag"}
+		g := New(&c).Disable(OptEnvFile).AddSource(mapSource{"name": "map"}, in.priority).Precedence(in.order...)
+		if err := g.Load(); err != nil {
+			return output{}, err
+		}
+		p, _ := g.ProvenanceOf("Name")
+		return output{Name: c.Name, Order: g.sourceOrder(), Detail: p.String()}, nil
+	}
+	cases := trial.Cases[input, output]{
+		"default": {
+			Input: input{priority: PriorityEnv},
+			Expected: output{
+				Name:   "flag",
+				Order:  "default, env, map, file, flag",
+				Detail: `Name: "flag" from flag -name (overrides file test/test.toml:1 "toml", map "map", env NAME "env", default "")`,
+			},
+		},
+		"env over file": {
+			Input: input{order: []SourceKind{SourceFile, SourceEnv}, priority: PriorityEnv},
+			Expected: output{
+				Name:   "map",
+				Order:  "default, file, env, map", // map stays after env
+				Detail: `Name: "map" from map (overrides env NAME "env", file test/test.toml:1 "toml", default "")`,
+			},
+		},
+		"anchored to file": {
+			Input: input{order: []SourceKind{SourceFile, SourceEnv, SourceFlag}, priority: PriorityFile + 1},
+			Expected: output{
+				Name:   "flag",
+				Order:  "default, file, map, env, flag",
+				Detail: `Name: "flag" from flag -name (overrides env NAME "env", map "map", file test/test.toml:1 "toml", default "")`,
+			},
+		},
+		"added source by name": {
+			Input: input{order: []SourceKind{SourceFile, SourceEnv, "map", SourceFlag}, priority: PriorityEnv},
+			Expected: output{
+				Name:   "flag",
+				Order:  "default, file, env, map, flag",
+				Detail: `Name: "flag" from flag -name (overrides map "map", env NAME "env", file test/test.toml:1 "toml", default "")`,
+			},
+		},
+		"unknown source": {
+			Input:       input{order: []SourceKind{SourceFile, "enviroment"}, priority: PriorityEnv},
+			ExpectedErr: errors.New(`precedence: unknown source "enviroment"`),
+		},
+	}
+	trial.New(fn, cases).SubTest(t)
+}
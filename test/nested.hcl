@@ -0,0 +1,5 @@
+name = "app"
+
+db {
+  host = "localhost"
+}
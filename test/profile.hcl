@@ -0,0 +1,11 @@
+name = "base"
+value = 10
+
+profiles "prod" {
+  name = "prod"
+}
+
+profiles "stage" {
+  name = "stage"
+  value = 20
+}
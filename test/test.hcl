@@ -0,0 +1,9 @@
+# simple struct values
+name = "hcl"
+value = 10
+enable = true // inline comment
+/* the time uses
+   the format tag */
+time = "2010-08-10"
+float64 = 99.9
+dura = "10s"
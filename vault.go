@@ -0,0 +1,253 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hydronica/go-config/internal/encode/env"
+)
+
+// VaultSource is a Source that reads a secret from a Vault KV version 2 engine.
+// The secret's keys are mapped onto the config fields using the env variable
+// naming rules (see ConfigMap), so the key db_password or db.password sets
+// the field DB.Password. Nested objects are joined to their parent key.
+//
+// On reload (each Unmarshal after the first, see goConfig.Reload) the token and
+// the secret's lease are renewed. Reload again after RenewAfter so their TTL
+// does not expire while the app is running.
+type VaultSource struct {
+	Addr  string // Vault address, defaults to the VAULT_ADDR env variable
+	Mount string // KV engine mount path (ie "secret")
+	Path  string // secret path within the mount (ie "myapp/prod")
+
+	// Token used to authenticate. Defaults to the VAULT_TOKEN env variable
+	// and then the contents of TokenFile.
+	Token string
+
+	// TokenFile defaults to ~/.vault-token
+	TokenFile string
+
+	Client *http.Client
+
+	loaded    bool
+	leaseID   string
+	renewable bool
+	leaseTTL  time.Duration // of the secret, 0 when it does not expire
+	tokenTTL  time.Duration // 0 when the token does not expire
+}
+
+// NewVaultSource creates a VaultSource for the KV v2 secret at mount/path
+// with a 10 second timeout.
+//
+//	config.New(&c).AddSource(config.NewVaultSource("secret", "myapp/prod"), config.PriorityFile)
+func NewVaultSource(mount, path string) *VaultSource {
+	return &VaultSource{
+		Mount:  mount,
+		Path:   path,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Source
+func (v *VaultSource) Name() string { return "vault" }
+
+// Location implements Locator
+func (v *VaultSource) Location() string {
+	return v.addr() + "/v1/" + v.dataPath()
+}
+
+// vaultSecret is the response of a KV v2 read
+type vaultSecret struct {
+	LeaseID       string `json:"lease_id"`
+	LeaseDuration int64  `json:"lease_duration"` // seconds
+	Renewable     bool   `json:"renewable"`
+	Data          struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+// vaultToken is the response of a token lookup or renewal
+type vaultToken struct {
+	Data struct {
+		TTL int64 `json:"ttl"` // seconds
+	} `json:"data"`
+	Auth struct {
+		LeaseDuration int64 `json:"lease_duration"` // seconds
+	} `json:"auth"`
+}
+
+// Unmarshal reads the secret into i. The token and secret lease are
+// renewed first when the source has already been loaded, otherwise
+// the token's TTL is looked up.
+func (v *VaultSource) Unmarshal(i interface{}) error {
+	token, err := v.token()
+	if err != nil {
+		return err
+	}
+	if v.loaded {
+		v.renew(token)
+	} else {
+		v.lookupToken(token)
+	}
+
+	var secret vaultSecret
+	if err := v.do(http.MethodGet, v.dataPath(), token, nil, &secret); err != nil {
+		return err
+	}
+	v.loaded, v.leaseID, v.renewable = true, secret.LeaseID, secret.Renewable
+	v.leaseTTL = time.Duration(secret.LeaseDuration) * time.Second
+
+	values := make(map[string]string)
+	flattenSecret("", secret.Data.Data, values)
+	d := &env.Decoder{
+		GetVal: func(k string) string { return values[k] },
+	}
+	return d.Unmarshal(i)
+}
+
+// RenewAfter is how long to wait before reloading the source so the token and
+// the secret's lease are renewed before they expire. It is two thirds of the
+// shorter TTL and 0 when neither expires (ie a root token and a KV secret).
+//
+//	for d := vault.RenewAfter(); d > 0; d = vault.RenewAfter() {
+//		time.Sleep(d)
+//		cfg.Reload()
+//	}
+func (v *VaultSource) RenewAfter() time.Duration {
+	ttl := v.tokenTTL
+	if v.leaseTTL > 0 && (ttl == 0 || v.leaseTTL < ttl) {
+		ttl = v.leaseTTL
+	}
+	return ttl * 2 / 3
+}
+
+// lookupToken sets the TTL of the token. Failures are logged as
+// the token may not be allowed to look itself up.
+func (v *VaultSource) lookupToken(token string) {
+	var t vaultToken
+	if err := v.do(http.MethodGet, "auth/token/lookup-self", token, nil, &t); err != nil {
+		log.Printf("vault: token lookup %v", err)
+		return
+	}
+	v.tokenTTL = time.Duration(t.Data.TTL) * time.Second
+}
+
+// renew the token and the secret's lease and set their new TTL. Failures
+// are logged as the current token may still be valid (ie a root token).
+func (v *VaultSource) renew(token string) {
+	var t vaultToken
+	if err := v.do(http.MethodPost, "auth/token/renew-self", token, nil, &t); err != nil {
+		log.Printf("vault: token renew %v", err)
+	} else {
+		v.tokenTTL = time.Duration(t.Auth.LeaseDuration) * time.Second
+	}
+	if v.leaseID == "" || !v.renewable {
+		return
+	}
+	body := map[string]string{"lease_id": v.leaseID}
+	if err := v.do(http.MethodPut, "sys/leases/renew", token, body, nil); err != nil {
+		log.Printf("vault: lease renew %v", err)
+	}
+}
+
+// do sends a request to the Vault api path and decodes the json response into out.
+func (v *VaultSource) do(method, path, token string, in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, v.addr()+"/v1/"+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("vault %s: status %s", path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("vault %s: %w", path, err)
+	}
+	return nil
+}
+
+func (v *VaultSource) addr() string {
+	if v.Addr != "" {
+		return strings.TrimSuffix(v.Addr, "/")
+	}
+	return strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+}
+
+// dataPath is the KV v2 api path of the secret (ie secret/data/myapp/prod)
+func (v *VaultSource) dataPath() string {
+	return strings.Trim(v.Mount, "/") + "/data/" + strings.Trim(v.Path, "/")
+}
+
+// token used to authenticate from Token, VAULT_TOKEN or TokenFile
+func (v *VaultSource) token() (string, error) {
+	if v.Token != "" {
+		return v.Token, nil
+	}
+	if t := os.Getenv("VAULT_TOKEN"); t != "" {
+		return t, nil
+	}
+	path := v.TokenFile
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("vault token: %w", err)
+		}
+		path = filepath.Join(home, ".vault-token")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("vault token: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// flattenSecret converts the secret keys to env names. Nested objects are
+// joined with their parent's key and lists are joined with a comma.
+func flattenSecret(prefix string, data map[string]interface{}, values map[string]string) {
+	for k, val := range data {
+		name := env.KeyName(k)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+		switch x := val.(type) {
+		case map[string]interface{}:
+			flattenSecret(name, x, values)
+		case []interface{}:
+			s := make([]string, len(x))
+			for i := range x {
+				s[i] = fmt.Sprint(x[i])
+			}
+			values[name] = strings.Join(s, ",")
+		case nil:
+		default:
+			values[name] = fmt.Sprint(x)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hydronica/trial"
+)
+
+func TestVaultSource(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/secret/data/myapp":
+			w.Write([]byte(`{"lease_id":"lease-1","lease_duration":600,"renewable":true,"data":{"data":{
+				"name":"vault","value":20,"pointer":{"count":3},"enable":true}}}`))
+		case "/v1/auth/token/lookup-self":
+			w.Write([]byte(`{"data":{"ttl":3600}}`))
+		case "/v1/auth/token/renew-self":
+			w.Write([]byte(`{"auth":{"lease_duration":300}}`))
+		case "/v1/sys/leases/renew":
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer func() {
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	}()
+	os.Args = []string{"go-config"}
+
+	v := NewVaultSource("secret", "myapp")
+	v.Addr = srv.URL
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFile, []byte("s.token\n"), 0o600)
+	v.TokenFile = tokenFile
+
+	count := 3
+	c := testStruct{Name: "default"}
+	cfg := New(&c).Disable(OptEnv|OptEnvFile).AddSource(v, PriorityFile)
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	exp := testStruct{Name: "vault", Value: 20, Enable: true, Pointer: &childStruct{Count: &count}}
+	if eq, diff := trial.Equal(c, exp); !eq {
+		t.Error(diff)
+	}
+	// the secret lease (600s) expires before the token (3600s)
+	if d := v.RenewAfter(); d != 400*time.Second {
+		t.Errorf("renew after %v != 400s", d)
+	}
+
+	// reload renews the token and lease
+	if err := cfg.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	expCalls := []string{
+		"GET /v1/auth/token/lookup-self",
+		"GET /v1/secret/data/myapp",
+		"POST /v1/auth/token/renew-self",
+		"PUT /v1/sys/leases/renew",
+		"GET /v1/secret/data/myapp",
+	}
+	if eq, diff := trial.Equal(calls, expCalls); !eq {
+		t.Error(diff)
+	}
+	// the renewed token (300s) expires first
+	if d := v.RenewAfter(); d != 200*time.Second {
+		t.Errorf("renew after %v != 200s", d)
+	}
+
+	v.Path = "missing"
+	if err := v.Unmarshal(&c); err == nil {
+		t.Error("expected error for a missing secret")
+	}
+	v.Token = "bad"
+	if err := v.Unmarshal(&c); err == nil {
+		t.Error("expected error for an invalid token")
+	}
+}